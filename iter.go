@@ -3,10 +3,27 @@
 package goiter
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -23,12 +40,52 @@ const (
 	ErrUnreadExhaustedIter              = "Iter.Unread called on exhausted iterator"
 	ErrColsGreaterThanZero              = "cols must be > 0"
 	ErrRowsGreaterThanZero              = "rows must be > 0"
+	ErrNGreaterThanZero                 = "n must be > 0"
+	ErrMaxCountGreaterThanZero          = "maxCount must be > 0"
+	ErrMinRunGreaterThanZero            = "minRun must be > 0"
+	ErrDistinctComparable               = "Distinct requires comparable values"
+	ErrTranslateComparable              = "Translate requires comparable values"
+	ErrNGreaterThanOrEqualZero          = "n must be >= 0"
+	ErrMaxLenExceeded                   = "Iter.MaxLen maximum number of values exceeded"
+	ErrToMapKeyValue                    = "ToMap requires KeyValue values"
+	ErrDuplicatesComparable             = "Duplicates requires comparable values"
+	ErrGroupByComparable                = "GroupBy requires comparable keys"
+	ErrPerKeyGreaterThanZero            = "perKey must be > 0"
+	ErrStratifiedSampleComparable       = "StratifiedSample requires comparable keys"
+	ErrRandCannotBeNil                  = "rand cannot be nil"
+	ErrRegexpCannotBeNil                = "regexp cannot be nil"
+	ErrToArrayOfArg                     = "ToArrayOf argument must be an array"
+	ErrToArrayOfTooFewValues            = "ToArrayOf iterator has fewer values than the array length"
+	ErrToArrayOfTooManyValues           = "ToArrayOf iterator has more values than the array length"
+	ErrOfMapInOrderMapArg               = "OfMapInOrder map argument must be a map"
+	ErrOfMapInOrderKeysArg              = "OfMapInOrder keys argument must be an array or slice of the map key type"
+	ErrSizeGreaterThanZero              = "size must be > 0"
+	ErrHalfLifeGreaterThanZero          = "halfLife must be > 0"
+	ErrStepCannotBeZero                 = "step cannot be 0"
+	ErrTabWidthGreaterThanZero          = "tabWidth must be > 0"
+	ErrMaxInFlightGreaterThanZero       = "maxInFlight must be > 0"
+	ErrUnzipKeyValue                    = "Unzip requires KeyValue values"
+	ErrCommonPrefixString               = "CommonPrefix requires string values"
+	ErrDelimCannotBeEmpty               = "delim cannot be empty"
+	ErrBufSizeGreaterThanZero           = "bufSize must be > 0"
+	ErrInvalidRuneEncoding              = "RuneEncoding must be UTF8, UTF16LE, or UTF16BE"
+	ErrOfJSONArrayNotArray              = "OfJSONArray requires a JSON array"
+	ErrToMapByComparable                = "ToMapBy requires comparable keys"
+	ErrDedupComparable                  = "Dedup requires comparable values"
+	ErrOfMapSortedArg                   = "OfMapSorted argument must be a map"
+	ErrOfFlattenAsTypeArg               = "OfFlattenAsType value must be an array or slice"
+	ErrFlattenArraySliceDepthArg        = "FlattenArraySliceDepth argument must be an array or slice"
+	ErrMaxDepthGreaterThanZero          = "maxDepth must be > 0, or -1 for unlimited"
+	ErrSpillFileRecordLength            = "OfSpillFile record length exceeds the remaining file size"
 	ErrIterableGeneratorCannotBeNil     = "Iterable.Generator cannot be nil"
 	ErrIterableGeneratorCannotReturnNil = "Iterable.Generator cannot return a nil iterating function"
 )
 
 var (
 	zeroUTF8Buffer = []byte{0, 0, 0, 0}
+
+	// dirEntriesBatchSize is the number of entries OfDirEntries reads from a directory at a time
+	dirEntriesBatchSize = 100
 )
 
 // ==== Iterator function generators
@@ -161,6 +218,40 @@ func ReaderIterFunc(src io.Reader) func() (interface{}, bool) {
 	}
 }
 
+// ReaderIterErrFunc iterates the bytes of an io.Reader, for use with NewIterErr.
+// For each byte in the Reader, returns (byte, true, nil).
+// When eof read, returns (0, false, nil).
+// When any other error occurs, returns (0, false, error), and all further calls return (0, false, nil).
+func ReaderIterErrFunc(src io.Reader) func() (interface{}, bool, error) {
+	var (
+		buf    = make([]byte, 1)
+		failed bool
+	)
+
+	return func() (interface{}, bool, error) {
+		// Once reading has failed, there is nothing more to read
+		if failed {
+			return 0, false, nil
+		}
+
+		for {
+			n, err := src.Read(buf)
+			if n == 1 {
+				return buf[0], true, nil
+			}
+
+			if err == io.EOF {
+				return 0, false, nil
+			}
+
+			if err != nil {
+				failed = true
+				return 0, false, err
+			}
+		}
+	}
+}
+
 // ReaderToRunesIterFunc iterates the bytes of an io.Reader, and interprets them as UTF-8 runes.
 // For each valid rune contained in the Reader, returns (rune, true).
 // When EOF read, returns (utf8.RuneError, false).
@@ -202,6 +293,100 @@ func ReaderToRunesIterFunc(src io.Reader) func() (interface{}, bool) {
 	}
 }
 
+// RuneEncoding is the encoding of runes in an io.Reader
+type RuneEncoding uint
+
+const (
+	// UTF8 is UTF-8 encoding
+	UTF8 RuneEncoding = iota
+	// UTF16LE is little endian UTF-16 encoding
+	UTF16LE
+	// UTF16BE is big endian UTF-16 encoding
+	UTF16BE
+)
+
+// ReaderToRunesEncodingIterFunc iterates the bytes of an io.Reader, and interprets them as runes in the given encoding.
+// UTF8 is handled by ReaderToRunesIterFunc.
+// For UTF16LE and UTF16BE, surrogate pairs are decoded into a single rune,
+// and an unpaired surrogate or a trailing odd byte is returned as (utf8.RuneError, true).
+// When EOF read, returns (utf8.RuneError, false).
+// When any other error occurs, panics with the error.
+// Panics if enc is not UTF8, UTF16LE, or UTF16BE.
+func ReaderToRunesEncodingIterFunc(src io.Reader, enc RuneEncoding) func() (interface{}, bool) {
+	var order binary.ByteOrder
+
+	switch enc {
+	case UTF8:
+		return ReaderToRunesIterFunc(src)
+	case UTF16LE:
+		order = binary.LittleEndian
+	case UTF16BE:
+		order = binary.BigEndian
+	default:
+		panic(ErrInvalidRuneEncoding)
+	}
+
+	var (
+		buf         = make([]byte, 2)
+		pending     rune
+		havePending bool
+		done        bool
+	)
+
+	// readUnit reads the next 16 bit code unit, if there is one
+	readUnit := func() (rune, bool) {
+		if havePending {
+			havePending = false
+			return pending, true
+		}
+
+		if done {
+			return 0, false
+		}
+
+		if _, err := io.ReadFull(src, buf); err != nil {
+			done = true
+
+			if err == io.ErrUnexpectedEOF {
+				// A trailing odd byte is an invalid code unit
+				return utf8.RuneError, true
+			}
+
+			if err != io.EOF {
+				panic(err)
+			}
+
+			return 0, false
+		}
+
+		return rune(order.Uint16(buf)), true
+	}
+
+	return func() (interface{}, bool) {
+		unit, haveIt := readUnit()
+		if !haveIt {
+			return utf8.RuneError, false
+		}
+
+		if !utf16.IsSurrogate(unit) {
+			return unit, true
+		}
+
+		next, haveIt := readUnit()
+		if !haveIt {
+			return utf8.RuneError, true
+		}
+
+		if r := utf16.DecodeRune(unit, next); r != utf8.RuneError {
+			return r, true
+		}
+
+		// Not a valid pair, the next unit may be the start of the next rune
+		pending, havePending = next, true
+		return utf8.RuneError, true
+	}
+}
+
 // ReaderToLinesIterFunc iterates the bytes of an io.Reader, and interprets them as runes.
 // Runes are read until an EOL sequence occurs (CR, LF, CRLF) or EOF occurs.
 // For each line contained in the Reader, returns (string, true), where the string does not contain an EOL sequence.
@@ -248,6 +433,76 @@ func ReaderToLinesIterFunc(src io.Reader) func() (interface{}, bool) {
 	}
 }
 
+// ReaderToLinesIterFuncDelim iterates the bytes of an io.Reader, and interprets them as runes.
+// Runes are read until the delim string occurs or EOF occurs.
+// For each line contained in the Reader, returns (string, true), where the string does not contain the delim string.
+// After the last line has been returned, all further calls return ("", false).
+// When any other error occurs (including invalid UTF-8 encoding), panics with the error.
+// Panics if delim is empty.
+func ReaderToLinesIterFuncDelim(src io.Reader, delim string) func() (interface{}, bool) {
+	if delim == "" {
+		panic(ErrDelimCannotBeEmpty)
+	}
+
+	// Use ReaderToRunesIterFunc to read individual runes until a line is read
+	var (
+		runesIter = ReaderToRunesIterFunc(src)
+		str       strings.Builder
+	)
+
+	return func() (interface{}, bool) {
+		str.Reset()
+
+		for {
+			codePoint, haveIt := runesIter()
+
+			if !haveIt {
+				if str.Len() > 0 {
+					return str.String(), true
+				}
+
+				return "", false
+			}
+
+			str.WriteRune(codePoint.(rune))
+
+			if line := str.String(); strings.HasSuffix(line, delim) {
+				return line[:len(line)-len(delim)], true
+			}
+		}
+	}
+}
+
+// ReaderToWordsIterFunc iterates the bytes of an io.Reader, and interprets them as whitespace separated words.
+// Words are split the same way as bufio.ScanWords, so runs of whitespace are skipped.
+// For each word contained in the Reader, returns (string, true).
+// After the last word has been returned, all further calls return ("", false).
+// When any other error occurs, panics with the error.
+func ReaderToWordsIterFunc(src io.Reader) func() (interface{}, bool) {
+	var (
+		scanner = bufio.NewScanner(src)
+		done    bool
+	)
+	scanner.Split(bufio.ScanWords)
+
+	return func() (interface{}, bool) {
+		if done {
+			return "", false
+		}
+
+		if scanner.Scan() {
+			return scanner.Text(), true
+		}
+
+		done = true
+		if err := scanner.Err(); err != nil {
+			panic(err)
+		}
+
+		return "", false
+	}
+}
+
 // FlattenArraySlice flattens an array or slice of any number of dimensions into a new slice of one dimension.
 // EG, an [][]int{{1, 2}, {3, 4, 5}} is flattened into an []interface{}{1,2,3,4,5}.
 // Note that in case where the element type is interface{}, a mixture of values and arrays/slices could be used.
@@ -259,25 +514,48 @@ func FlattenArraySlice(value interface{}) []interface{} {
 		panic("FlattenArraySlice argument must be an array or slice")
 	}
 
+	return flattenArraySlice(arraySlice, -1)
+}
+
+// FlattenArraySliceDepth is the same as FlattenArraySlice, except that only maxDepth levels are flattened,
+// where a maxDepth of -1 flattens all levels.
+// EG, with a maxDepth of 1, an [][]int{{1, 2}, {3}} is flattened into an []interface{}{[]int{1, 2}, []int{3}}.
+// Panics if the value is not an array or slice.
+// Panics if maxDepth is 0 or < -1.
+func FlattenArraySliceDepth(value interface{}, maxDepth int) []interface{} {
+	arraySlice := reflect.ValueOf(value)
+	if (arraySlice.Kind() != reflect.Array) && (arraySlice.Kind() != reflect.Slice) {
+		panic(ErrFlattenArraySliceDepthArg)
+	}
+
+	if (maxDepth == 0) || (maxDepth < -1) {
+		panic(ErrMaxDepthGreaterThanZero)
+	}
+
+	return flattenArraySlice(arraySlice, maxDepth)
+}
+
+// flattenArraySlice flattens up to maxDepth levels of an array or slice, where a maxDepth of -1 flattens all levels
+func flattenArraySlice(arraySlice reflect.Value, maxDepth int) []interface{} {
 	// Make a one dimensional slice
 	result := []interface{}{}
 
 	// Recursive function
-	var f func(reflect.Value)
-	f = func(currentArraySlice reflect.Value) {
+	var f func(reflect.Value, int)
+	f = func(currentArraySlice reflect.Value, depth int) {
 		// Iterate current array or slice
 		for i, num := 0, currentArraySlice.Len(); i < num; i++ {
 			val := reflect.ValueOf(currentArraySlice.Index(i).Interface())
 
-			// Recurse sub-arrays/slices
-			if (val.Kind() == reflect.Array) || (val.Kind() == reflect.Slice) {
-				f(val)
+			// Recurse sub-arrays/slices, unless the maximum depth has been flattened
+			if ((val.Kind() == reflect.Array) || (val.Kind() == reflect.Slice)) && ((maxDepth == -1) || (depth < maxDepth)) {
+				f(val, depth+1)
 			} else {
 				result = append(result, val.Interface())
 			}
 		}
 	}
-	f(arraySlice)
+	f(arraySlice, 1)
 
 	return result
 }
@@ -329,6 +607,11 @@ type Iter struct {
 	nextCalled bool
 	value      interface{}
 	buffer     []interface{}
+	err        error
+	errs       []error
+	iterErr    func() (interface{}, bool, error)
+	skippable  bool
+	closer     func()
 }
 
 // NewIter constructs an Iter from an iterating function.
@@ -343,6 +626,47 @@ func NewIter(iter func() (interface{}, bool)) *Iter {
 	return &Iter{iter: iter}
 }
 
+// NewIterErr constructs an Iter from an iterating function that can fail.
+// The function must return (nextItem, true, nil) for every item available to iterate,
+// then return (invalid, false, nil) on the next call after the last item.
+// The first time the function returns a non-nil error, the Iter stops and Err returns the error.
+// Once the function returns a false bool value or an error, the Iter will never call it again.
+// A function that has returned an error must remember it, and return (invalid, false, nil) on every later call.
+// Panics if iterErr is nil.
+func NewIterErr(iterErr func() (interface{}, bool, error)) *Iter {
+	if iterErr == nil {
+		panic(ErrNewIterNeedsIterator)
+	}
+
+	it := &Iter{iterErr: iterErr}
+	it.iter = func() (interface{}, bool) {
+		value, haveIt, err := iterErr()
+		if err != nil {
+			it.err = err
+			return nil, false
+		}
+
+		return value, haveIt
+	}
+
+	return it
+}
+
+// newDerivedIter constructs an Iter like NewIter, that reads its values from the given source Iters.
+// Closing the Iter also closes each non-nil source, so that Close can be called on the last Iter of a chain.
+func newDerivedIter(iter func() (interface{}, bool), sources ...*Iter) *Iter {
+	result := NewIter(iter)
+	result.closer = func() {
+		for _, source := range sources {
+			if source != nil {
+				source.Close()
+			}
+		}
+	}
+
+	return result
+}
+
 // Of constructs an Iter that iterates the items passed.
 // If any item is an array/slice/map/Iterable, it will be handled the same as any other type - the whole array/slice/map/Iterable will iterated as a single value.
 func Of(items ...interface{}) *Iter {
@@ -360,6 +684,52 @@ func OfFlatten(items interface{}) *Iter {
 	return NewIter(ArraySliceIterFunc(reflect.ValueOf(FlattenArraySlice(items))))
 }
 
+// OfFlattenAsType constructs an Iter that lazily flattens a multi-dimensional array or slice,
+// converting each value to the type of the given element.
+// The flattening is the same as FlattenArraySliceAsType, except that values are only read and converted as Next is called.
+// Panics if the value is not an array or slice.
+// Panics if the element is nil.
+// Next panics if a value is not convertible to the type of the given element.
+func OfFlattenAsType(value interface{}, elementVal interface{}) *Iter {
+	arraySlice := reflect.ValueOf(value)
+	if (arraySlice.Kind() != reflect.Array) && (arraySlice.Kind() != reflect.Slice) {
+		panic(ErrOfFlattenAsTypeArg)
+	}
+
+	if elementVal == nil {
+		panic(ErrValueCannotBeNil)
+	}
+
+	// Stack of arrays/slices being iterated and the index of the next element of each
+	var (
+		typ     = reflect.TypeOf(elementVal)
+		stack   = []reflect.Value{arraySlice}
+		indexes = []int{0}
+	)
+
+	return NewIter(func() (interface{}, bool) {
+		for last := len(stack) - 1; last >= 0; last = len(stack) - 1 {
+			currentArraySlice, idx := stack[last], indexes[last]
+			if idx == currentArraySlice.Len() {
+				stack, indexes = stack[:last], indexes[:last]
+				continue
+			}
+			indexes[last]++
+
+			// Descend into sub-arrays/slices
+			val := reflect.ValueOf(currentArraySlice.Index(idx).Interface())
+			if (val.Kind() == reflect.Array) || (val.Kind() == reflect.Slice) {
+				stack, indexes = append(stack, val), append(indexes, 0)
+				continue
+			}
+
+			return val.Convert(typ).Interface(), true
+		}
+
+		return nil, false
+	})
+}
+
 // OfElements constructs an Iter that iterates the elements of the item passed.
 // See ElementsIterFunc for details of how different types are handled.
 func OfElements(item interface{}) *Iter {
@@ -377,584 +747,3103 @@ func OfReader(src io.Reader) *Iter {
 	return NewIter(ReaderIterFunc(src))
 }
 
+// OfReaderErr constructs an Iter that iterates the bytes of a reader, without panicking on read errors.
+// The returned func returns the error that stopped the iteration, or nil if the iteration stopped at eof or has not stopped yet.
+// The same error is also available from Iter.Err.
+// See ReaderIterErrFunc for details.
+func OfReaderErr(src io.Reader) (*Iter, func() error) {
+	it := NewIterErr(ReaderIterErrFunc(src))
+
+	return it, it.Err
+}
+
+// OfReaderBuffered constructs an Iter that iterates the bytes of a reader, which is wrapped in a bufio.Reader of the given size.
+// The bytes are the same as OfReader, but the reader is read bufSize bytes at a time rather than one byte at a time.
+// Panics if bufSize <= 0.
+func OfReaderBuffered(src io.Reader, bufSize int) *Iter {
+	if bufSize <= 0 {
+		panic(ErrBufSizeGreaterThanZero)
+	}
+
+	reader := bufio.NewReaderSize(src, bufSize)
+
+	return NewIter(func() (interface{}, bool) {
+		abyte, err := reader.ReadByte()
+		if err != nil {
+			if err != io.EOF {
+				panic(err)
+			}
+
+			return 0, false
+		}
+
+		return abyte, true
+	})
+}
+
 // OfReaderRunes constructs an Iter that iterates the runes of a reader.
 // See ReaderToRunesIterFunc for details.
 func OfReaderRunes(src io.Reader) *Iter {
 	return NewIter(ReaderToRunesIterFunc(src))
 }
 
+// OfReaderRunesSkipBOM constructs an Iter that iterates the runes of a reader, skipping a leading UTF-8 byte order mark.
+// Only a byte order mark at the start of the reader is skipped, any U+FEFF later in the reader is returned.
+// See ReaderToRunesIterFunc for details.
+func OfReaderRunesSkipBOM(src io.Reader) *Iter {
+	var (
+		runesIter = ReaderToRunesIterFunc(src)
+		first     = true
+	)
+
+	return NewIter(func() (interface{}, bool) {
+		codePoint, haveIt := runesIter()
+
+		if first {
+			first = false
+
+			if haveIt && (codePoint == '\uFEFF') {
+				return runesIter()
+			}
+		}
+
+		return codePoint, haveIt
+	})
+}
+
+// OfReaderRunesEncoding constructs an Iter that iterates the runes of a reader in the given encoding.
+// See ReaderToRunesEncodingIterFunc for details.
+func OfReaderRunesEncoding(src io.Reader, enc RuneEncoding) *Iter {
+	return NewIter(ReaderToRunesEncodingIterFunc(src, enc))
+}
+
 // OfReaderLines constructs an Iter that iterates the lines of a reader.
 // See ReaderToLinesIterFunc for details.
 func OfReaderLines(src io.Reader) *Iter {
 	return NewIter(ReaderToLinesIterFunc(src))
 }
 
-// Next returns true if there is another item to be read by Value.
-// Once Next returns false, further calls to Next or Value panic.
-func (it *Iter) Next() bool {
-	// Die if iterator already exhausted
-	if it.iter == nil {
-		panic(ErrNextExhaustedIter)
-	}
+// OfReaderLinesDelim constructs an Iter that iterates the lines of a reader, where lines are separated by the given delimiter.
+// See ReaderToLinesIterFuncDelim for details.
+func OfReaderLinesDelim(src io.Reader, delim string) *Iter {
+	return NewIter(ReaderToLinesIterFuncDelim(src, delim))
+}
 
-	// Check buffer first in case items have been unread
-	if l := len(it.buffer); l > 0 {
-		it.nextCalled = true
-		it.value = it.buffer[l-1]
-		it.buffer = it.buffer[:l-1]
-		return true
+// OfReaderWords constructs an Iter that iterates the whitespace separated words of a reader.
+// See ReaderToWordsIterFunc for details.
+func OfReaderWords(src io.Reader) *Iter {
+	return NewIter(ReaderToWordsIterFunc(src))
+}
+
+// OfMapInOrder constructs an Iter that iterates the map entries as KeyValue instances, in the order of the given keys.
+// The keys must be an array or slice whose element type is the map key type.
+// Any keys that are not in the map are skipped.
+// Panics if the map is not a map, or the keys are not an array or slice of the map key type.
+func OfMapInOrder(m interface{}, keys interface{}) *Iter {
+	aMap := reflect.ValueOf(m)
+	if aMap.Kind() != reflect.Map {
+		panic(ErrOfMapInOrderMapArg)
 	}
 
-	// Try to get next item
-	if value, haveIt := it.iter(); haveIt {
-		// If we have it, keep the value for call to Value() and return true
-		it.nextCalled = true
-		it.value = value
-		return true
+	keySlice := reflect.ValueOf(keys)
+	if ((keySlice.Kind() != reflect.Array) && (keySlice.Kind() != reflect.Slice)) || (keySlice.Type().Elem() != aMap.Type().Key()) {
+		panic(ErrOfMapInOrderKeysArg)
 	}
 
-	// First call with no more items, mark done and return false
-	it.iter = nil
-	return false
-}
+	var (
+		num = keySlice.Len()
+		idx int
+	)
 
-// Value returns the value retrieved by the prior call to Next.
-// In the case of iterating a map, each value will be returned as a KeyValue instance, passed by value.
-// Panics if the iterator is exhausted.
-// Panics if Next has not been called since the last time Value was called.
-func (it *Iter) Value() interface{} {
-	if it.iter == nil {
-		panic(ErrValueExhaustedIter)
-	}
+	return NewIter(func() (interface{}, bool) {
+		for idx < num {
+			key := keySlice.Index(idx)
+			idx++
 
-	if !it.nextCalled {
-		panic(ErrValueNextFirst)
-	}
+			if value := aMap.MapIndex(key); value.IsValid() {
+				return KeyValue{Key: key.Interface(), Value: value.Interface()}, true
+			}
+		}
 
-	// Clear nextCalled flag
-	it.nextCalled = false
-	return it.value
+		return nil, false
+	})
 }
 
-// ValueOfType reads the value and converts it to a value with the same type as the given value.
-// EG, if an int is passed, it converts the value to an int.
-// The result will have to be type asserted.
-// Panics is value is nil.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to the type of the given value.
-func (it *Iter) ValueOfType(value interface{}) interface{} {
-	if value == nil {
-		panic(ErrValueCannotBeNil)
+// OfMapSorted constructs an Iter that iterates the map entries as KeyValue instances, sorted by key according to less.
+// The entries are copied and sorted when the Iter is constructed.
+// Panics if the map is not a map.
+func OfMapSorted(m interface{}, less func(a, b interface{}) bool) *Iter {
+	aMap := reflect.ValueOf(m)
+	if aMap.Kind() != reflect.Map {
+		panic(ErrOfMapSortedArg)
 	}
 
-	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(value)).Interface()
+	entries := make([]KeyValue, 0, aMap.Len())
+	for mapIter := aMap.MapRange(); mapIter.Next(); {
+		entries = append(entries, KeyValue{Key: mapIter.Key().Interface(), Value: mapIter.Value().Interface()})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i].Key, entries[j].Key) })
+
+	return NewIter(ArraySliceIterFunc(reflect.ValueOf(entries)))
 }
 
-// NextValue retrieves the next value for cases where you know the iterator has another value.
-// Panics if Next() or Value() panics.
-func (it *Iter) NextValue() interface{} {
-	it.Next()
-	return it.Value()
+// OfChannel constructs an Iter that iterates the values received from a channel, until the channel is closed.
+// Each call to Next blocks until a value is received or the channel is closed.
+func OfChannel(ch <-chan interface{}) *Iter {
+	return NewIter(func() (interface{}, bool) {
+		value, haveIt := <-ch
+		return value, haveIt
+	})
+}
+
+// OfRange constructs an Iter that iterates the ints start, start + step, start + 2 * step, ...
+// If step is positive, iteration continues while the value is < end.
+// If step is negative, iteration continues while the value is > end.
+// If start is already at or past end, the Iter is empty.
+// Panics if step is 0.
+func OfRange(start, end, step int) *Iter {
+	if step == 0 {
+		panic(ErrStepCannotBeZero)
+	}
+
+	var (
+		value   = start
+		done    = ((step > 0) && (start >= end)) || ((step < 0) && (start <= end))
+		absStep = uint(step)
+	)
+
+	if step < 0 {
+		absStep = uint(-step)
+	}
+
+	return NewIter(func() (interface{}, bool) {
+		if done {
+			return nil, false
+		}
+
+		// Stop before adding step if the distance left to end is not more than step, so value cannot overflow.
+		// The distance is converted to uint, as it can be larger than the largest int.
+		result := value
+		if step > 0 {
+			done = uint(end-value) <= absStep
+		} else {
+			done = uint(value-end) <= absStep
+		}
+
+		if !done {
+			value += step
+		}
+
+		return result, true
+	})
+}
+
+// Generate constructs an infinite Iter that returns the result of calling fn each time.
+// Terminal operations like ToSlice will never return, so they must be paired with Limit, TakeWhile, etc.
+func Generate(fn func() interface{}) *Iter {
+	return NewIter(func() (interface{}, bool) {
+		return fn(), true
+	})
+}
+
+// OfSpillFile constructs an Iter that iterates the []byte records of a file written by Iter.SpillToFile.
+// The file is opened immediately and read lazily, and is closed once the last record has been read.
+// If the Iter is abandoned before it is exhausted, Close must be called on it, or on an Iter chained after it, to close the file.
+// If the file cannot be opened, the error is returned.
+// If the file cannot be read, is truncated, or has a record length that exceeds the rest of the file, the Iter stops and Err returns the error.
+func OfSpillFile(path string) (*Iter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	var (
+		reader    = bufio.NewReader(file)
+		remaining = uint64(info.Size())
+		length    uint64
+		closed    bool
+		closeFile = func() {
+			if !closed {
+				closed = true
+				file.Close()
+			}
+		}
+	)
+
+	result := NewIterErr(func() (interface{}, bool, error) {
+		// Once the file is closed, due to eof or an error, there is nothing more to read
+		if closed {
+			return nil, false, nil
+		}
+
+		// Each record is preceded by its length
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			closeFile()
+
+			if err == io.EOF {
+				return nil, false, nil
+			}
+
+			return nil, false, err
+		}
+		remaining -= 8
+
+		// Don't trust a length that cannot fit in the rest of the file, a corrupt length could be huge
+		if length > remaining {
+			closeFile()
+			return nil, false, errors.New(ErrSpillFileRecordLength)
+		}
+		remaining -= length
+
+		record := make([]byte, length)
+		if _, err := io.ReadFull(reader, record); err != nil {
+			closeFile()
+			return nil, false, err
+		}
+
+		return record, true, nil
+	})
+	result.closer = closeFile
+
+	return result, nil
+}
+
+// OfGob constructs an Iter that iterates the gob encoded values written by ToGob.
+// The same concrete types must be registered with gob.Register as when the values were written.
+// If a value cannot be decoded, the Iter stops and Err returns the error.
+func OfGob(src io.Reader) *Iter {
+	var (
+		decoder = gob.NewDecoder(src)
+		err     error
+	)
+
+	return NewIterErr(func() (interface{}, bool, error) {
+		// Once decoding has failed, there is nothing more to decode
+		if err != nil {
+			return nil, false, nil
+		}
+
+		var value interface{}
+		if err = decoder.Decode(&value); err != nil {
+			if err == io.EOF {
+				return nil, false, nil
+			}
+
+			return nil, false, err
+		}
+
+		return value, true, nil
+	})
+}
+
+// OfCSVReader constructs an Iter that iterates the records of a comma separated reader as []string.
+// See OfCSVReaderComma for details.
+func OfCSVReader(src io.Reader) *Iter {
+	return OfCSVReaderComma(src, ',')
+}
+
+// OfCSVReaderComma constructs an Iter that iterates the records of a reader as []string, where fields are separated by comma.
+// The reader is parsed by a csv.Reader, so records must all have the same number of fields as the first record.
+// If a record cannot be parsed, the Iter stops and Err returns the error, and no further records are read.
+func OfCSVReaderComma(src io.Reader, comma rune) *Iter {
+	var (
+		reader = csv.NewReader(src)
+		failed bool
+	)
+	reader.Comma = comma
+
+	return NewIterErr(func() (interface{}, bool, error) {
+		// Once a record has failed to parse, no further records are read
+		if failed {
+			return nil, false, nil
+		}
+
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, false, nil
+			}
+
+			failed = true
+			return nil, false, err
+		}
+
+		return record, true, nil
+	})
+}
+
+// OfJSONArray constructs an Iter that lazily iterates the elements of a JSON array, decoding each element into an interface{}.
+// Elements are decoded as encoding/json decodes into an interface{}, so numbers are float64, objects are map[string]interface{}, etc.
+// Only the current element is held in memory, so arrays larger than memory can be iterated.
+// If the reader does not contain an array, or an element cannot be decoded, the Iter stops and Err returns the error.
+func OfJSONArray(src io.Reader) *Iter {
+	var (
+		decoder = json.NewDecoder(src)
+		first   = true
+		err     error
+	)
+
+	return NewIterErr(func() (interface{}, bool, error) {
+		// Once decoding has failed, there is nothing more to decode
+		if err != nil {
+			return nil, false, nil
+		}
+
+		if first {
+			first = false
+
+			var token json.Token
+			if token, err = decoder.Token(); err != nil {
+				return nil, false, err
+			}
+
+			if token != json.Delim('[') {
+				err = errors.New(ErrOfJSONArrayNotArray)
+				return nil, false, err
+			}
+		}
+
+		if !decoder.More() {
+			// Read the closing bracket
+			if _, err = decoder.Token(); err != nil {
+				return nil, false, err
+			}
+
+			return nil, false, nil
+		}
+
+		var value interface{}
+		if err = decoder.Decode(&value); err != nil {
+			return nil, false, err
+		}
+
+		return value, true, nil
+	})
+}
+
+// OfJSONLines constructs an Iter that iterates a reader of newline delimited JSON values, decoding each line into an interface{}.
+// Elements are decoded as encoding/json decodes into an interface{}, so numbers are float64, objects are map[string]interface{}, etc.
+// Blank lines are skipped.
+// If a line cannot be read or decoded, the Iter stops and Err returns the error, and no further lines are read.
+func OfJSONLines(src io.Reader) *Iter {
+	var (
+		reader = bufio.NewReader(src)
+		failed bool
+	)
+
+	return NewIterErr(func() (interface{}, bool, error) {
+		// Once a line has failed, no further lines are read
+		if failed {
+			return nil, false, nil
+		}
+
+		for {
+			line, err := reader.ReadBytes('\n')
+			if (err != nil) && (err != io.EOF) {
+				failed = true
+				return nil, false, err
+			}
+
+			if len(bytes.TrimSpace(line)) > 0 {
+				var value interface{}
+				if err := json.Unmarshal(line, &value); err != nil {
+					failed = true
+					return nil, false, err
+				}
+
+				return value, true, nil
+			}
+
+			if err == io.EOF {
+				return nil, false, nil
+			}
+		}
+	})
+}
+
+// OfDirEntries constructs an Iter that lazily iterates the entries of a directory as os.DirEntry values, in directory order.
+// Entries are read from the directory in batches, so that large directories are not read all at once.
+// The directory is closed once the Iter is exhausted.
+// If the Iter is abandoned before it is exhausted, Close must be called on it, or on an Iter chained after it, to close the directory.
+// If the directory cannot be opened, the error is returned.
+// If an entry cannot be read, the Iter stops and Err returns the error.
+func OfDirEntries(dirPath string) (*Iter, error) {
+	dir, err := os.Open(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		batch    []os.DirEntry
+		closed   bool
+		closeDir = func() {
+			if !closed {
+				closed = true
+				dir.Close()
+			}
+		}
+	)
+
+	result := NewIterErr(func() (interface{}, bool, error) {
+		if len(batch) == 0 {
+			// Once the directory is closed, due to eof or an error, there is nothing more to read
+			if closed {
+				return nil, false, nil
+			}
+
+			var err error
+			if batch, err = dir.ReadDir(dirEntriesBatchSize); len(batch) == 0 {
+				closeDir()
+
+				if (err == nil) || (err == io.EOF) {
+					return nil, false, nil
+				}
+
+				return nil, false, err
+			}
+		}
+
+		entry := batch[0]
+		batch = batch[1:]
+
+		return entry, true, nil
+	})
+	result.closer = closeDir
+
+	return result, nil
+}
+
+// OfWalk constructs an Iter that lazily iterates the path of each file under root, in the same order as filepath.WalkDir.
+// Directories are not returned, and each directory is only read when the walk reaches it.
+// Symbolic links are returned as files and not followed, so symbolic link loops cannot occur.
+// If root is not a directory, only root is returned.
+// Any directory that cannot be read is skipped, and the error can be retrieved with Errors.
+func OfWalk(root string) *Iter {
+	// Stack of the directories being walked, and the entries of each that have not been walked yet
+	type walkDir struct {
+		path    string
+		entries []os.DirEntry
+	}
+
+	var (
+		result *Iter
+		stack  []walkDir
+		first  = true
+	)
+
+	// push reads the entries of a directory, collecting the error if it cannot be read
+	push := func(path string) {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			result.errs = append(result.errs, err)
+		}
+
+		stack = append(stack, walkDir{path: path, entries: entries})
+	}
+
+	result = NewIter(func() (interface{}, bool) {
+		if first {
+			first = false
+
+			info, err := os.Lstat(root)
+			if err != nil {
+				result.errs = append(result.errs, err)
+				return nil, false
+			}
+
+			if !info.IsDir() {
+				return root, true
+			}
+
+			push(root)
+		}
+
+		for last := len(stack) - 1; last >= 0; last = len(stack) - 1 {
+			if len(stack[last].entries) == 0 {
+				stack = stack[:last]
+				continue
+			}
+
+			entry := stack[last].entries[0]
+			stack[last].entries = stack[last].entries[1:]
+			path := filepath.Join(stack[last].path, entry.Name())
+
+			if entry.IsDir() {
+				push(path)
+				continue
+			}
+
+			return path, true
+		}
+
+		return nil, false
+	})
+
+	return result
+}
+
+// Iterate constructs an infinite Iter that returns seed, fn(seed), fn(fn(seed)), ...
+// Terminal operations like ToSlice will never return, so they must be paired with Limit, TakeWhile, etc.
+func Iterate(seed interface{}, fn func(interface{}) interface{}) *Iter {
+	var (
+		value = seed
+		first = true
+	)
+
+	return NewIter(func() (interface{}, bool) {
+		if first {
+			first = false
+		} else {
+			value = fn(value)
+		}
+
+		return value, true
+	})
+}
+
+// Repeat constructs an Iter that returns value n times.
+// If n < 0, value is repeated infinitely.
+func Repeat(value interface{}, n int) *Iter {
+	count := 0
+
+	return NewIter(func() (interface{}, bool) {
+		if (n >= 0) && (count >= n) {
+			return nil, false
+		}
+
+		count++
+		return value, true
+	})
+}
+
+// Cycle constructs an infinite Iter that returns the items passed in order, over and over.
+// If no items are passed, the Iter is empty.
+func Cycle(items ...interface{}) *Iter {
+	idx := 0
+
+	return NewIter(func() (interface{}, bool) {
+		if len(items) == 0 {
+			return nil, false
+		}
+
+		value := items[idx]
+		idx = (idx + 1) % len(items)
+		return value, true
+	})
+}
+
+// OfSyncMap constructs an Iter that iterates the entries of a sync.Map as KeyValue instances.
+// The entries are copied when the Iter is constructed, so later changes to the map do not affect the Iter.
+// As with any map, the order of the entries is unspecified.
+// If the map is nil, the Iter is empty.
+func OfSyncMap(m *sync.Map) *Iter {
+	entries := []interface{}{}
+
+	if m != nil {
+		m.Range(func(key, value interface{}) bool {
+			entries = append(entries, KeyValue{Key: key, Value: value})
+			return true
+		})
+	}
+
+	return NewIter(ArraySliceIterFunc(reflect.ValueOf(entries)))
+}
+
+// Zip constructs an Iter that lazily pairs the nth value of a with the nth value of b as a KeyValue{Key: a value, Value: b value}.
+// Iteration stops as soon as either Iter is exhausted.
+// Since a is read before b, if b is exhausted first, one extra value of a has been read and discarded.
+// If a is exhausted first, b is left with any remaining values unread.
+func Zip(a, b *Iter) *Iter {
+	return newDerivedIter(func() (interface{}, bool) {
+		if a.Next() && b.Next() {
+			return KeyValue{Key: a.Value(), Value: b.Value()}, true
+		}
+
+		return nil, false
+	}, a, b)
+}
+
+// Concat constructs an Iter that lazily returns all the values of each Iter passed in order, skipping any nil Iters.
+// No values are read from an Iter until all previous Iters have been exhausted.
+func Concat(iters ...*Iter) *Iter {
+	idx := 0
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for ; idx < len(iters); idx++ {
+			if iter := iters[idx]; (iter != nil) && iter.Next() {
+				return iter.Value(), true
+			}
+		}
+
+		return nil, false
+	}, iters...)
+}
+
+// RoundRobinLongest constructs an Iter that lazily returns one value from each Iter in turn, until all Iters are exhausted.
+// Once an Iter is exhausted, fill is returned in its place, until the longest Iter is exhausted.
+// This keeps the values aligned, so that every nth value comes from the same Iter, like columns of a table.
+// A nil Iter is treated as an empty Iter.
+// Each round reads one value from every Iter before returning any of them, in order to determine if the round contains any values.
+func RoundRobinLongest(fill interface{}, iters ...*Iter) *Iter {
+	var (
+		num       = len(iters)
+		exhausted = make([]bool, num)
+		round     []interface{}
+	)
+
+	for i, iter := range iters {
+		exhausted[i] = iter == nil
+	}
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if len(round) == 0 {
+			// Read next round
+			haveAny := false
+			for i, iter := range iters {
+				if !exhausted[i] && iter.Next() {
+					round = append(round, iter.Value())
+					haveAny = true
+				} else {
+					exhausted[i] = true
+					round = append(round, fill)
+				}
+			}
+
+			if !haveAny {
+				round = nil
+				return nil, false
+			}
+		}
+
+		value := round[0]
+		round = round[1:]
+		return value, true
+	}, iters...)
+}
+
+// PollWithBackoff constructs an Iter that repeatedly calls fetch to poll a source that may not always have a value available.
+// See PollWithBackoffSleep, which this function calls with time.Sleep.
+func PollWithBackoff(fetch func() (interface{}, bool, error), initial, maxDelay time.Duration) *Iter {
+	return PollWithBackoffSleep(fetch, initial, maxDelay, time.Sleep)
+}
+
+// PollWithBackoffSleep constructs an Iter that repeatedly calls fetch to poll a source that may not always have a value available.
+// When fetch returns (value, true, nil), the value is returned.
+// When fetch returns (invalid, false, nil), sleep is called before calling fetch again.
+// The first sleep after a value is the initial duration, and each consecutive sleep is twice as long, up to the maxDelay duration.
+// When fetch returns a non-nil error, the Iter stops and Err returns the error.
+// Since the Iter only stops on an error, terminal operations like ToSlice should be paired with Limit or TakeWhile.
+func PollWithBackoffSleep(fetch func() (interface{}, bool, error), initial, maxDelay time.Duration, sleep func(time.Duration)) *Iter {
+	var (
+		delay  = initial
+		result *Iter
+	)
+
+	result = NewIter(func() (interface{}, bool) {
+		for {
+			value, haveIt, err := fetch()
+			if err != nil {
+				result.err = err
+				return nil, false
+			}
+
+			if haveIt {
+				delay = initial
+				return value, true
+			}
+
+			sleep(delay)
+			if delay *= 2; delay > maxDelay {
+				delay = maxDelay
+			}
+		}
+	})
+
+	return result
+}
+
+// ZipN constructs an Iter that lazily returns a []interface{} of the nth value of each Iter passed.
+// Iteration stops as soon as any Iter is exhausted, so the Iters before it have each had one extra value read and discarded.
+// If no Iters are passed, or any Iter is nil, the result is empty.
+func ZipN(iters ...*Iter) *Iter {
+	done := len(iters) == 0
+	for _, iter := range iters {
+		done = done || (iter == nil)
+	}
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if done {
+			return nil, false
+		}
+
+		tuple := make([]interface{}, len(iters))
+		for i, iter := range iters {
+			if !iter.Next() {
+				done = true
+				return nil, false
+			}
+
+			tuple[i] = iter.Value()
+		}
+
+		return tuple, true
+	}, iters...)
+}
+
+// Next returns true if there is another item to be read by Value.
+// Once Next returns false, further calls to Next or Value panic.
+func (it *Iter) Next() bool {
+	// Die if iterator already exhausted
+	if it.iter == nil {
+		panic(ErrNextExhaustedIter)
+	}
+
+	// Check buffer first in case items have been unread
+	if l := len(it.buffer); l > 0 {
+		it.nextCalled = true
+		it.value = it.buffer[l-1]
+		it.buffer = it.buffer[:l-1]
+		return true
+	}
+
+	// Try to get next item
+	if value, haveIt := it.iter(); haveIt {
+		// If we have it, keep the value for call to Value() and return true
+		it.nextCalled = true
+		it.value = value
+		return true
+	}
+
+	// First call with no more items, mark done and return false
+	it.iter = nil
+	return false
+}
+
+// TryNext is an alternative to Next and Value that returns errors rather than panicking.
+// For every item, returns (item, true, nil).
+// After the last item, returns (nil, false, Err()), where Err() is nil unless the Iter stopped early due to an error.
+// If called again after that, returns (nil, false, error) with the message ErrNextExhaustedIter.
+func (it *Iter) TryNext() (interface{}, bool, error) {
+	if it.iter == nil {
+		return nil, false, errors.New(ErrNextExhaustedIter)
+	}
+
+	if it.Next() {
+		return it.Value(), true, nil
+	}
+
+	return nil, false, it.err
+}
+
+// Value returns the value retrieved by the prior call to Next.
+// In the case of iterating a map, each value will be returned as a KeyValue instance, passed by value.
+// Panics if the iterator is exhausted.
+// Panics if Next has not been called since the last time Value was called.
+func (it *Iter) Value() interface{} {
+	if it.iter == nil {
+		panic(ErrValueExhaustedIter)
+	}
+
+	if !it.nextCalled {
+		panic(ErrValueNextFirst)
+	}
+
+	// Clear nextCalled flag
+	it.nextCalled = false
+	return it.value
+}
+
+// Err returns the error that caused this Iter to stop early, if any.
+// Only some Iters can detect a problem while iterating, and the error is not passed along to Iters derived from this Iter.
+func (it *Iter) Err() error {
+	return it.err
+}
+
+// Close ends this Iter, so that the next call to Next returns false, and releases any resources it holds.
+// Iters that hold resources, like those returned by Fork, OfSpillFile, OfDirEntries, and FromSeq, release them once exhausted,
+// so Close only needs to be called if such an Iter is abandoned before it is exhausted.
+// Closing an Iter also closes the Iters it reads from, like this Iter for Map or Filter, or each Iter passed to Concat,
+// so a chain of Iters can be closed by closing the last one.
+// Iters that are produced while iterating, like those returned by the function passed to FlatMap, are not closed.
+// Calling Close more than once, or on an exhausted Iter, has no further effect.
+func (it *Iter) Close() {
+	if it.closer != nil {
+		it.closer()
+		it.closer = nil
+	}
+
+	if it.iter != nil {
+		it.iter = NoValueIterFunc
+		it.buffer = nil
+	}
+}
+
+// Errors returns the errors that were skipped by an Iter returned by SkipErrors or OfWalk, in the order they occurred.
+// Returns nil for any other Iter, or if no errors have occurred.
+func (it *Iter) Errors() []error {
+	return it.errs
+}
+
+// ValueOfType reads the value and converts it to a value with the same type as the given value.
+// EG, if an int is passed, it converts the value to an int.
+// The result will have to be type asserted.
+// Panics is value is nil.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to the type of the given value.
+func (it *Iter) ValueOfType(value interface{}) interface{} {
+	if value == nil {
+		panic(ErrValueCannotBeNil)
+	}
+
+	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(value)).Interface()
+}
+
+// NextValue retrieves the next value for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Value() panics.
+func (it *Iter) NextValue() interface{} {
+	it.Next()
+	return it.Value()
 }
 
 // NextValueOfType retrieves the next value with the same type as the given value for cases where you know the iterator has another value.
-// Panics if Next() or ValueOfType() panics.
+// Panics if there is no next value, or if ValueOfType() panics.
 func (it *Iter) NextValueOfType(value interface{}) interface{} {
 	it.Next()
-	return it.ValueOfType(value)
+	return it.ValueOfType(value)
+}
+
+// BoolValue reads the value and converts it to a bool.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a bool.
+func (it *Iter) BoolValue() bool {
+	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(true)).Bool()
+}
+
+// NextBoolValue retrieves the next value as a bool for cases where you know the iterator has another value.
+// Panics if there is no next value, or if BoolValue() panics.
+func (it *Iter) NextBoolValue() bool {
+	it.Next()
+	return it.BoolValue()
+}
+
+// ByteValue reads the value and converts it to a byte.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a byte.
+func (it *Iter) ByteValue() byte {
+	return byte(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(byte(0))).Uint())
+}
+
+// NextByteValue retrieves the next value as a byte for cases where you know the iterator has another value.
+// Panics if there is no next value, or if ByteValue() panics.
+func (it *Iter) NextByteValue() byte {
+	it.Next()
+	return it.ByteValue()
+}
+
+// RuneValue reads the value and converts it to a rune.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a rune.
+func (it *Iter) RuneValue() rune {
+	return rune(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(rune(0))).Int())
+}
+
+// NextRuneValue retrieves the next value as a rune for cases where you know the iterator has another value.
+// Panics if there is no next value, or if RuneValue() panics.
+func (it *Iter) NextRuneValue() rune {
+	it.Next()
+	return it.RuneValue()
+}
+
+// IntValue reads the value and converts it to an int.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to an int.
+func (it *Iter) IntValue() int {
+	return int(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(0)).Int())
+}
+
+// NextIntValue retrieves the next value as an int for cases where you know the iterator has another value.
+// Panics if there is no next value, or if IntValue() panics.
+func (it *Iter) NextIntValue() int {
+	it.Next()
+	return it.IntValue()
+}
+
+// Int8Value reads the value and converts it to an int8.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to an int8.
+func (it *Iter) Int8Value() int8 {
+	return int8(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(int8(0))).Int())
+}
+
+// NextInt8Value retrieves the next value as an int8 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Int8Value() panics.
+func (it *Iter) NextInt8Value() int8 {
+	it.Next()
+	return it.Int8Value()
+}
+
+// Int16Value reads the value and converts it to an int16.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to an int16.
+func (it *Iter) Int16Value() int16 {
+	return int16(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(int16(0))).Int())
+}
+
+// NextInt16Value retrieves the next value as an int16 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Int16Value() panics.
+func (it *Iter) NextInt16Value() int16 {
+	it.Next()
+	return it.Int16Value()
+}
+
+// Int32Value reads the value and converts it to an int32.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to an int32.
+func (it *Iter) Int32Value() int32 {
+	return int32(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(int32(0))).Int())
+}
+
+// NextInt32Value retrieves the next value as an int32 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Int32Value() panics.
+func (it *Iter) NextInt32Value() int32 {
+	it.Next()
+	return it.Int32Value()
+}
+
+// Int64Value reads the value and converts it to an int64.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to an int64.
+func (it *Iter) Int64Value() int64 {
+	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(int64(0))).Int()
+}
+
+// NextInt64Value retrieves the next value as an int64 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Int64Value() panics.
+func (it *Iter) NextInt64Value() int64 {
+	it.Next()
+	return it.Int64Value()
+}
+
+// UintValue reads the value and converts it to a uint.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a uint.
+func (it *Iter) UintValue() uint {
+	return uint(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint(0))).Uint())
+}
+
+// NextUintValue retrieves the next value as a uint for cases where you know the iterator has another value.
+// Panics if there is no next value, or if UintValue() panics.
+func (it *Iter) NextUintValue() uint {
+	it.Next()
+	return it.UintValue()
+}
+
+// Uint8Value reads the value and converts it to a uint8.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a uint8.
+func (it *Iter) Uint8Value() uint8 {
+	return uint8(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint8(0))).Uint())
+}
+
+// NextUint8Value retrieves the next value as a uint8 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Uint8Value() panics.
+func (it *Iter) NextUint8Value() uint8 {
+	it.Next()
+	return it.Uint8Value()
+}
+
+// Uint16Value reads the value and converts it to a uint16.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a uint16.
+func (it *Iter) Uint16Value() uint16 {
+	return uint16(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint16(0))).Uint())
+}
+
+// NextUint16Value retrieves the next value as a uint16 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Uint16Value() panics.
+func (it *Iter) NextUint16Value() uint16 {
+	it.Next()
+	return it.Uint16Value()
+}
+
+// Uint32Value reads the value and converts it to a uint32.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a uint32.
+func (it *Iter) Uint32Value() uint32 {
+	return uint32(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint32(0))).Uint())
+}
+
+// NextUint32Value retrieves the next value as a uint32 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Uint32Value() panics.
+func (it *Iter) NextUint32Value() uint32 {
+	it.Next()
+	return it.Uint32Value()
+}
+
+// Uint64Value reads the value and converts it to a uint64.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a uint64.
+func (it *Iter) Uint64Value() uint64 {
+	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint64(0))).Uint()
+}
+
+// NextUint64Value retrieves the next value as a uint64 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Uint64Value() panics.
+func (it *Iter) NextUint64Value() uint64 {
+	it.Next()
+	return it.Uint64Value()
+}
+
+// Float32Value reads the value and converts it to a float32.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a float32.
+func (it *Iter) Float32Value() float32 {
+	return float32(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(float32(0))).Float())
+}
+
+// NextFloat32Value retrieves the next value as a float32 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Float32Value() panics.
+func (it *Iter) NextFloat32Value() float32 {
+	it.Next()
+	return it.Float32Value()
+}
+
+// Float64Value reads the value and converts it to a float64.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a float64.
+func (it *Iter) Float64Value() float64 {
+	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(float64(0))).Float()
+}
+
+// NextFloat64Value retrieves the next value as a float64 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Float64Value() panics.
+func (it *Iter) NextFloat64Value() float64 {
+	it.Next()
+	return it.Float64Value()
+}
+
+// Complex64Value reads the value and converts it to a complex64.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a complex64.
+func (it *Iter) Complex64Value() complex64 {
+	return complex64(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(complex64(0))).Complex())
+}
+
+// NextComplex64Value retrieves the next value as a complex64 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Complex64Value() panics.
+func (it *Iter) NextComplex64Value() complex64 {
+	it.Next()
+	return it.Complex64Value()
+}
+
+// Complex128Value reads the value and converts it to a complex128.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a complex128.
+func (it *Iter) Complex128Value() complex128 {
+	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(complex128(0))).Complex()
+}
+
+// NextComplex128Value retrieves the next value as a complex128 for cases where you know the iterator has another value.
+// Panics if there is no next value, or if Complex128Value() panics.
+func (it *Iter) NextComplex128Value() complex128 {
+	it.Next()
+	return it.Complex128Value()
+}
+
+// stringOf converts a value to a string.
+// Panics if the value is not convertible to a string.
+func stringOf(value interface{}) string {
+	return fmt.Sprintf("%s", reflect.ValueOf(value).Convert(reflect.TypeOf("")))
+}
+
+// StringValue reads the value and converts it to a string.
+// Panics if Value() method panics.
+// Panics if the value is not convertible to a string.
+func (it *Iter) StringValue() string {
+	return stringOf(it.Value())
+}
+
+// NextStringValue retrieves the next value as a string for cases where you know the iterator has another value.
+// Panics if there is no next value, or if StringValue() panics.
+func (it *Iter) NextStringValue() string {
+	it.Next()
+	return it.StringValue()
+}
+
+// Unread places the given value at the end of an internal buffer of unread values.
+// It is up to the caller to unread correctly.
+// Example:
+// - the source has values 1,2,3
+// - values 1,2 have been iterated, 3 has not
+// - caller can choose to unread 2, so that Next/Value returns 2 from buffer without consulting source
+// - calling Next again consults source to read 3
+// - caller can unread 3,2,1, so that Next/Value returns 1,2,3 without consulting source
+// - calling Next again returns false
+// There is nothing preventing the caller from reading 1,2,3 and unreading 1,2,3 causing Next/Value to return 3,2,1.
+// Panics if the iterator is exhausted.
+func (it *Iter) Unread(val interface{}) {
+	// Die if iterator already exhausted
+	if it.iter == nil {
+		panic(ErrUnreadExhaustedIter)
+	}
+
+	it.buffer = append(it.buffer, val)
+}
+
+// SplitIntoRows splits the iterator into rows of at most the number of columns specified.
+// Since the number of items to iterate is not known, the algorithm fills across the first row from left to right,
+// then fills across the second row, and so on.
+// The original ordering is retained by iterating each row from left to right.
+// If the number of items <= the number of columns, a single row is returned.
+// This operation will exhaust the iter.
+// Panics if the iter has already been exhausted.
+// Panics if cols = 0.
+func (it *Iter) SplitIntoRows(cols uint) [][]interface{} {
+	if cols == 0 {
+		panic(ErrColsGreaterThanZero)
+	}
+
+	var (
+		split = [][]interface{}{}
+		row   = make([]interface{}, 0, cols)
+		idx   uint
+	)
+
+	for it.Next() {
+		row = append(row, it.Value())
+		idx++
+
+		if idx == cols {
+			split = append(split, row)
+			row = make([]interface{}, 0, cols)
+			idx = 0
+		}
+	}
+
+	// If len == 0, must be a corner case: no items, or an exact multiple of n items.
+	// Otherwise, row contains a partial slice of the last < n items.
+	if len(row) > 0 {
+		split = append(split, row)
+	}
+
+	return split
+}
+
+// SplitIntoRowsOf is a version of SplitIntoRows where the slice type is the same as the type of the given value.
+// EG, if a value of type int is passed, a [][]int is returned.
+// This operation will exhaust the iter.
+// Panics if the iter has already been exhausted.
+// Panics if cols = 0.
+// Panics is value is nil.
+// Panics if any value is not convertible to the type of the given value.
+func (it *Iter) SplitIntoRowsOf(cols uint, value interface{}) interface{} {
+	if cols == 0 {
+		panic(ErrColsGreaterThanZero)
+	}
+
+	if value == nil {
+		panic(ErrValueCannotBeNil)
+	}
+
+	var (
+		intCols = int(cols)
+		typ     = reflect.TypeOf(value)
+		split   = reflect.MakeSlice(reflect.SliceOf(reflect.SliceOf(typ)), 0, 0)
+		row     = reflect.MakeSlice(reflect.SliceOf(typ), 0, intCols)
+		idx     uint
+	)
+
+	for it.Next() {
+		row = reflect.Append(row, reflect.ValueOf(it.Value()).Convert(typ))
+		idx++
+
+		if idx == cols {
+			split = reflect.Append(split, row)
+			row = reflect.MakeSlice(reflect.SliceOf(typ), 0, intCols)
+			idx = 0
+		}
+	}
+
+	// If len == 0, must be a corner case: no items, or an exact multiple of n items.
+	// Otherwise, row contains a partial slice of the last < n items.
+	if row.Len() > 0 {
+		split = reflect.Append(split, row)
+	}
+
+	return split.Interface()
+}
+
+// SplitIntoColumns splits the iterator into columns with at most the number of rows specified.
+// The algorithm reads all the items into a slice first to determine the number of them and ensures that each row has the same number of columns, except for a remainder spread across one or more rows.
+// EG, if 23 items exist and rows = 5, 23 / 5 = 4 r 3, so the first 3 rows have 5 items (4 + 1 from remainder), the last 2 have 4: 3 * 5 + 2 * 4 = 15 + 8 = 23.
+// If the number of items <= the number of rows, then the number of rows = number of items, 1 item per row.
+// This method is simply the transpose operation of SplitIntoRows.
+// This operation will exhaust the iter.
+// Panics if the iter has already been exhausted.
+// Panics if rows = 0.
+func (it *Iter) SplitIntoColumns(rows uint) [][]interface{} {
+	if rows == 0 {
+		panic(ErrRowsGreaterThanZero)
+	}
+
+	// Collect values into a slice first
+	var (
+		values         = it.ToSlice()
+		numValues      = len(values)
+		numRows        = int(rows)
+		numItems, rmdr = numValues / numRows, numValues % numRows
+		start, end     int
+		split          = [][]interface{}{}
+	)
+
+	if numValues < numRows {
+		// Fewer items than requested number of rows, actual number of rows = number of items
+		numRows = numValues
+
+		// Each row has 1 item, no remainder
+		numItems, rmdr = 1, 0
+	}
+
+	for i := 0; i < numRows; i++ {
+		// start, end = indexes for a subslice of values for this row
+		end = start + numItems
+		if rmdr > 0 {
+			// Add one extra item from remainder
+			end++
+			rmdr--
+		}
+		split = append(split, values[start:end])
+
+		// next row start index is current row end index (start is inclusive, end is exclusive)
+		start = end
+	}
+
+	return split
+}
+
+// SplitIntoColumnsOf is a version of SplitIntoColumns where the slice type is the same as the type of the given value.
+// This operation will exhaust the iter.
+// Panics if the iter has already been exhausted.
+// Panics if rows = 0.
+// Panics if value is nil.
+// Panics if any value is not convertible to the type of the given value.
+func (it *Iter) SplitIntoColumnsOf(rows uint, value interface{}) interface{} {
+	if rows == 0 {
+		panic(ErrRowsGreaterThanZero)
+	}
+
+	if value == nil {
+		panic(ErrValueCannotBeNil)
+	}
+
+	// Collect values into a slice first
+	var (
+		values         = it.ToSlice()
+		numValues      = len(values)
+		numRows        = int(rows)
+		numItems, rmdr = numValues / numRows, numValues % numRows
+		start, end     int
+		typ            = reflect.TypeOf(value)
+	)
+
+	if numValues < numRows {
+		// Fewer items than requested number of rows, actual number of rows = number of items
+		numRows = numValues
+
+		// Each row has 1 item, no remainder
+		numItems, rmdr = 1, 0
+	}
+
+	// Allocate number of rows now we know for sure how many there are
+	split := reflect.MakeSlice(reflect.SliceOf(reflect.SliceOf(typ)), numRows, numRows)
+
+	for i := 0; i < numRows; i++ {
+		// start, end = indexes for a subslice of values for this row
+		end = start + numItems
+		if rmdr > 0 {
+			// Add one extra item from remainder
+			end++
+			rmdr--
+		}
+
+		row := reflect.MakeSlice(reflect.SliceOf(typ), end-start, end-start)
+		for j, colIdx := start, 0; j < end; j, colIdx = j+1, colIdx+1 {
+			row.Index(colIdx).Set(reflect.ValueOf(values[j]).Convert(typ))
+		}
+		split.Index(i).Set(row)
+
+		// next row start index is current row end index (start is inclusive, end is exclusive)
+		start = end
+	}
+
+	return split.Interface()
+}
+
+// ToSlice collects the elements into a slice
+func (it *Iter) ToSlice() []interface{} {
+	slice := []interface{}{}
+
+	for it.Next() {
+		slice = append(slice, it.Value())
+	}
+
+	return slice
+}
+
+// ToSliceOf returns a slice of all elements, where the slice type is the same as the type of the given value.
+// EG, if a value of type int is passed, a []int is returned.
+// Panics if value is nil.
+// Panics if any value is not convertible to the type of the given value.
+func (it *Iter) ToSliceOf(value interface{}) interface{} {
+	if value == nil {
+		panic(ErrValueCannotBeNil)
+	}
+
+	var (
+		typ   = reflect.TypeOf(value)
+		slice = reflect.MakeSlice(reflect.SliceOf(typ), 0, 0)
+	)
+
+	for it.Next() {
+		slice = reflect.Append(slice, reflect.ValueOf(it.Value()).Convert(typ))
+	}
+
+	return slice.Interface()
+}
+
+// BatchUntil returns an Iter of []interface{} batches of the values of this Iter.
+// Each value is offered to shouldFlush along with the current batch before it is added:
+// if shouldFlush returns true, the current batch is returned and a new batch is started with the value.
+// shouldFlush is not called for an empty batch, so every batch contains at least one value.
+// The final partial batch is returned after this Iter is exhausted.
+func (it *Iter) BatchUntil(shouldFlush func(batch []interface{}, next interface{}) bool) *Iter {
+	var (
+		batch []interface{}
+		done  bool
+	)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for !done {
+			if !it.Next() {
+				done = true
+				break
+			}
+
+			value := it.Value()
+			if (len(batch) > 0) && shouldFlush(batch, value) {
+				result := batch
+				batch = []interface{}{value}
+				return result, true
+			}
+
+			batch = append(batch, value)
+		}
+
+		// Source is exhausted, return final partial batch if there is one
+		if len(batch) > 0 {
+			result := batch
+			batch = nil
+			return result, true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// SkipEvery returns an Iter that skips every nth value of this Iter, and returns the rest.
+// EG, if n = 3, the values at 0-based indexes 2, 5, 8, ... are skipped.
+// If n = 1, all values are skipped.
+// Panics if n = 0.
+func (it *Iter) SkipEvery(n uint) *Iter {
+	if n == 0 {
+		panic(ErrNGreaterThanZero)
+	}
+
+	var idx uint
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for it.Next() {
+			value := it.Value()
+
+			// Skip the nth value, and start counting again
+			if idx++; idx == n {
+				idx = 0
+				continue
+			}
+
+			return value, true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Map returns an Iter that lazily applies fn to each value of this Iter.
+func (it *Iter) Map(fn func(interface{}) interface{}) *Iter {
+	return newDerivedIter(func() (interface{}, bool) {
+		if it.Next() {
+			return fn(it.Value()), true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Neighbors returns an Iter of [3]interface{}{prev, curr, next} for each value of this Iter,
+// where curr is the value, prev is the value before it, and next is the value after it.
+// The first value has a nil prev, and the last value has a nil next.
+// At most one value beyond the current value is read from this Iter.
+func (it *Iter) Neighbors() *Iter {
+	var (
+		prev, curr interface{}
+		first      = true
+		haveCurr   bool
+	)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if first {
+			first = false
+			if haveCurr = it.Next(); haveCurr {
+				curr = it.Value()
+			}
+		}
+
+		if !haveCurr {
+			return nil, false
+		}
+
+		// Read ahead one value, if there is one
+		var next interface{}
+		haveNext := it.Next()
+		if haveNext {
+			next = it.Value()
+		}
+
+		result := [3]interface{}{prev, curr, next}
+		prev, curr, haveCurr = curr, next, haveNext
+
+		return result, true
+	}, it)
+}
+
+// Filter returns an Iter that lazily returns only the values of this Iter for which pred returns true.
+func (it *Iter) Filter(pred func(interface{}) bool) *Iter {
+	return newDerivedIter(func() (interface{}, bool) {
+		for it.Next() {
+			if value := it.Value(); pred(value) {
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
+}
+
+// textReader is the io.Reader returned by Iter.TextReader
+type textReader struct {
+	iter    *Iter
+	sep     string
+	buf     []byte
+	started bool
+	done    bool
+}
+
+// Read is io.Reader interface
+func (tr *textReader) Read(p []byte) (int, error) {
+	n := 0
+
+	for n < len(p) {
+		// Only convert the next value once the bytes of the last value have all been read
+		if len(tr.buf) == 0 {
+			if tr.done || !tr.iter.Next() {
+				tr.done = true
+				break
+			}
+
+			str := tr.iter.StringValue()
+			if tr.started {
+				str = tr.sep + str
+			}
+
+			tr.started = true
+			tr.buf = []byte(str)
+		}
+
+		copied := copy(p[n:], tr.buf)
+		n += copied
+		tr.buf = tr.buf[copied:]
+	}
+
+	if (n == 0) && tr.done && (len(p) > 0) {
+		return 0, io.EOF
+	}
+
+	return n, nil
+}
+
+// TextReader returns an io.Reader of the values of this Iter converted to strings and separated by sep.
+// Values are converted as they are needed, in the same way as StringValue, so the whole text is never built in memory.
+// Reading all the bytes of the io.Reader will exhaust the iter.
+func (it *Iter) TextReader(sep string) io.Reader {
+	return &textReader{iter: it, sep: sep}
+}
+
+// Join returns the values of this Iter converted to strings and separated by sep.
+// Values are converted in the same way as StringValue.
+// This operation will exhaust the iter.
+// If the iter is empty, an empty string is returned.
+func (it *Iter) Join(sep string) string {
+	var str strings.Builder
+
+	for started := false; it.Next(); started = true {
+		if started {
+			str.WriteString(sep)
+		}
+
+		str.WriteString(it.StringValue())
+	}
+
+	return str.String()
+}
+
+// BatchTimeOrCount returns an Iter of []interface{} batches of the values of this Iter,
+// where a batch ends when it contains maxCount values, or when the next value is more than maxAge after the first value of the batch.
+// The time of each value is provided by timeFn.
+// See BatchUntil.
+// Panics if maxCount <= 0.
+func (it *Iter) BatchTimeOrCount(maxCount int, maxAge time.Duration, timeFn func(interface{}) time.Time) *Iter {
+	if maxCount <= 0 {
+		panic(ErrMaxCountGreaterThanZero)
+	}
+
+	return it.BatchUntil(func(batch []interface{}, next interface{}) bool {
+		return (len(batch) >= maxCount) || (timeFn(next).Sub(timeFn(batch[0])) > maxAge)
+	})
+}
+
+// ForEach calls fn with each value of this Iter, in order.
+// This operation will exhaust the iter.
+func (it *Iter) ForEach(fn func(interface{})) {
+	for it.Next() {
+		fn(it.Value())
+	}
+}
+
+// FilterRuns returns an Iter that only returns values of this Iter that are part of a run of at least minRun consecutive equal values.
+// Values are compared with reflect.DeepEqual.
+// Each run is read completely before any of its values are returned.
+// Panics if minRun <= 0.
+func (it *Iter) FilterRuns(minRun int) *Iter {
+	if minRun <= 0 {
+		panic(ErrMinRunGreaterThanZero)
+	}
+
+	var (
+		first    = true
+		next     interface{}
+		haveNext bool
+		pending  []interface{}
+	)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if first {
+			first = false
+			if haveNext = it.Next(); haveNext {
+				next = it.Value()
+			}
+		}
+
+		for len(pending) == 0 {
+			if !haveNext {
+				return nil, false
+			}
+
+			// Read a run of equal values, keeping the first unequal value for the next run
+			run := []interface{}{next}
+			for haveNext = it.Next(); haveNext; haveNext = it.Next() {
+				value := it.Value()
+				if !reflect.DeepEqual(run[0], value) {
+					next = value
+					break
+				}
+
+				run = append(run, value)
+			}
+
+			if len(run) >= minRun {
+				pending = run
+			}
+		}
+
+		value := pending[0]
+		pending = pending[1:]
+		return value, true
+	}, it)
+}
+
+// Count returns the number of values of this Iter.
+// This operation will exhaust the iter.
+func (it *Iter) Count() int {
+	count := 0
+
+	for it.Next() {
+		it.Value()
+		count++
+	}
+
+	return count
+}
+
+// Dot returns the dot product of the values of this Iter and the given Iter, converting values in the same way as Float64Value.
+// Iteration stops as soon as either Iter is exhausted, so the other may be left partially consumed.
+// Panics if any value is not convertible to a float64.
+func (it *Iter) Dot(other *Iter) float64 {
+	var result float64
+
+	for it.Next() && other.Next() {
+		result += it.Float64Value() * other.Float64Value()
+	}
+
+	return result
+}
+
+// FlatMap returns an Iter that lazily calls fn with each value of this Iter, and returns all the values of each Iter fn returns.
+// A nil Iter returned by fn is treated as an empty Iter.
+func (it *Iter) FlatMap(fn func(interface{}) *Iter) *Iter {
+	var subIter *Iter
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for {
+			// Continue to return values from current sub iter until it is empty
+			if subIter != nil {
+				if subIter.Next() {
+					return subIter.Value(), true
+				}
+
+				subIter = nil
+			}
+
+			if !it.Next() {
+				return nil, false
+			}
+
+			subIter = fn(it.Value())
+		}
+	}, it)
+}
+
+// MinMaxScale returns the values of this Iter rescaled to the range [0, 1], converting values in the same way as Float64Value.
+// The minimum value becomes 0, the maximum value becomes 1, and all other values are proportionally in between.
+// If all values are the same, they all become 0.
+// All values have to be read before any can be scaled.
+// This operation will exhaust the iter.
+// If the iter is empty, an allocated empty slice is returned.
+// Panics if any value is not convertible to a float64.
+func (it *Iter) MinMaxScale() []float64 {
+	var (
+		result = []float64{}
+		lo, hi float64
+	)
+
+	for it.Next() {
+		value := it.Float64Value()
+		if (len(result) == 0) || (value < lo) {
+			lo = value
+		}
+
+		if (len(result) == 0) || (value > hi) {
+			hi = value
+		}
+
+		result = append(result, value)
+	}
+
+	for i, value := range result {
+		if hi == lo {
+			result[i] = 0
+		} else {
+			result[i] = (value - lo) / (hi - lo)
+		}
+	}
+
+	return result
 }
 
-// BoolValue reads the value and converts it to a bool.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a bool.
-func (it *Iter) BoolValue() bool {
-	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(true)).Bool()
+// checkComparable panics with the given message if the value cannot be used as a map key.
+// The dynamic values of any interface, struct, and array fields are checked, since a comparable type like KeyValue
+// can still hold an incomparable value like a slice.
+func checkComparable(value interface{}, msg string) {
+	if (value != nil) && !reflect.ValueOf(value).Comparable() {
+		panic(msg)
+	}
 }
 
-// NextBoolValue retrieves the next value as a bool for cases where you know the iterator has another value.
-// Panics if Next() or BoolValue() panics.
-func (it *Iter) NextBoolValue() bool {
-	it.Next()
-	return it.BoolValue()
+// Distinct returns an Iter that lazily returns each value of this Iter only the first time it occurs.
+// Panics if a value is not comparable, as it cannot be a map key.
+func (it *Iter) Distinct() *Iter {
+	seen := map[interface{}]bool{}
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for it.Next() {
+			value := it.Value()
+			checkComparable(value, ErrDistinctComparable)
+
+			if !seen[value] {
+				seen[value] = true
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
 }
 
-// ByteValue reads the value and converts it to a byte.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a byte.
-func (it *Iter) ByteValue() byte {
-	return byte(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(byte(0))).Uint())
+// Trace returns an Iter that lazily calls logger with the given name, the 0-based index, and each value of this Iter, then returns the value unchanged.
+// Using a different name for each stage of a chain of Iters makes it easy to see what each stage returns.
+func (it *Iter) Trace(name string, logger func(name string, index int, value interface{})) *Iter {
+	index := 0
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if it.Next() {
+			value := it.Value()
+			logger(name, index, value)
+			index++
+			return value, true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Peek returns an Iter that lazily calls fn with each value of this Iter, then returns the value unchanged.
+func (it *Iter) Peek(fn func(interface{})) *Iter {
+	return newDerivedIter(func() (interface{}, bool) {
+		if it.Next() {
+			value := it.Value()
+			fn(value)
+			return value, true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// CountByOf returns a map of the number of values of this Iter for each key returned by the key function,
+// where the map key type is the same as the type of the given keyProto.
+// EG, if a keyProto of type rune is passed, a map[rune]int is returned.
+// This operation will exhaust the iter.
+// Panics if keyProto is nil.
+// Panics if any key is not convertible to the type of keyProto.
+func (it *Iter) CountByOf(key func(interface{}) interface{}, keyProto interface{}) interface{} {
+	if keyProto == nil {
+		panic(ErrValueCannotBeNil)
+	}
+
+	var (
+		typ    = reflect.TypeOf(keyProto)
+		counts = reflect.MakeMap(reflect.MapOf(typ, reflect.TypeOf(0)))
+	)
+
+	for it.Next() {
+		var (
+			k     = reflect.ValueOf(key(it.Value())).Convert(typ)
+			count = counts.MapIndex(k)
+		)
+
+		if count.IsValid() {
+			counts.SetMapIndex(k, reflect.ValueOf(int(count.Int())+1))
+		} else {
+			counts.SetMapIndex(k, reflect.ValueOf(1))
+		}
+	}
+
+	return counts.Interface()
+}
+
+// Limit returns an Iter that lazily returns at most the first n values of this Iter.
+// No more than n values are read from this Iter, so it may be used with an infinite Iter.
+func (it *Iter) Limit(n uint) *Iter {
+	var count uint
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if (count < n) && it.Next() {
+			count++
+			return it.Value(), true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Accumulate returns an Iter that lazily returns the values of this Iter joined so far by sep.
+// EG, if the values are "a", "b", "c" and sep is "-", then "a", "a-b", "a-b-c" are returned.
+// Values are converted in the same way as StringValue.
+// Panics if any value is not convertible to a string.
+func (it *Iter) Accumulate(sep string) *Iter {
+	var (
+		str     strings.Builder
+		started bool
+	)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if it.Next() {
+			if started {
+				str.WriteString(sep)
+			}
+
+			started = true
+			str.WriteString(it.StringValue())
+			return str.String(), true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Skip returns an Iter that lazily discards the first n values of this Iter, and returns the rest.
+func (it *Iter) Skip(n uint) *Iter {
+	skipped := false
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if !skipped {
+			skipped = true
+			for i := uint(0); i < n; i++ {
+				if !it.Next() {
+					return nil, false
+				}
+
+				it.Value()
+			}
+		}
+
+		if it.Next() {
+			return it.Value(), true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Fork returns an Iter that lazily returns the values of this Iter, while also passing each value to a second Iter that consume receives.
+// The consume function is called in a separate goroutine, and the returned Iter blocks on each value until consume reads it.
+// If consume returns without exhausting its Iter, the returned Iter no longer waits for it.
+// The Iter given to consume ends when the returned Iter is exhausted or closed.
+// If the returned Iter is abandoned before it is exhausted, Close must be called on it, or on an Iter chained after it, so that the goroutine does not leak.
+func (it *Iter) Fork(consume func(*Iter)) *Iter {
+	var (
+		ch       = make(chan interface{})
+		done     = make(chan struct{})
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+		stopFn   = func() { stopOnce.Do(func() { close(stop) }) }
+	)
+
+	go func() {
+		defer close(done)
+
+		consume(NewIter(func() (interface{}, bool) {
+			select {
+			case value := <-ch:
+				return value, true
+			case <-stop:
+				return nil, false
+			}
+		}))
+	}()
+
+	result := NewIter(func() (interface{}, bool) {
+		if it.Next() {
+			value := it.Value()
+
+			select {
+			case ch <- value:
+			case <-done:
+			case <-stop:
+			}
+
+			return value, true
+		}
+
+		stopFn()
+		return nil, false
+	})
+	result.closer = func() {
+		stopFn()
+		it.Close()
+	}
+
+	return result
+}
+
+// TakeWhile returns an Iter that lazily returns the values of this Iter until pred returns false for the first time.
+// The first value that pred returns false for is read from this Iter, but is not returned.
+// No further values are read from this Iter after that.
+func (it *Iter) TakeWhile(pred func(interface{}) bool) *Iter {
+	return newDerivedIter(func() (interface{}, bool) {
+		if it.Next() {
+			if value := it.Value(); pred(value) {
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Translate returns an Iter that lazily replaces each value of this Iter with the value it maps to in table.
+// Values that are not in the table are returned unchanged if passthrough is true, otherwise they are discarded.
+// Panics if a value is not comparable, as it cannot be a map key.
+func (it *Iter) Translate(table map[interface{}]interface{}, passthrough bool) *Iter {
+	return newDerivedIter(func() (interface{}, bool) {
+		for it.Next() {
+			value := it.Value()
+			checkComparable(value, ErrTranslateComparable)
+
+			if translated, haveIt := table[value]; haveIt {
+				return translated, true
+			}
+
+			if passthrough {
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
+}
+
+// DropWhile returns an Iter that lazily discards the values of this Iter until pred returns false for the first time,
+// then returns that value and all remaining values.
+func (it *Iter) DropWhile(pred func(interface{}) bool) *Iter {
+	dropped := false
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for it.Next() {
+			value := it.Value()
+			if dropped || !pred(value) {
+				dropped = true
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
+}
+
+// MaxLen returns an Iter that lazily returns the values of this Iter, as long as there are no more than n values.
+// If this Iter has more than n values, the returned Iter stops after n values and Err returns an error.
+// Unlike Limit, one value beyond n values is read from this Iter to detect the overflow.
+// Panics if n < 0.
+func (it *Iter) MaxLen(n int) *Iter {
+	if n < 0 {
+		panic(ErrNGreaterThanOrEqualZero)
+	}
+
+	var (
+		count  int
+		result *Iter
+	)
+
+	result = newDerivedIter(func() (interface{}, bool) {
+		if !it.Next() {
+			return nil, false
+		}
+
+		if count == n {
+			result.err = errors.New(ErrMaxLenExceeded)
+			return nil, false
+		}
+
+		count++
+		return it.Value(), true
+	}, it)
+
+	return result
+}
+
+// Stats returns the count, mean, population variance, and population standard deviation of the values of this Iter,
+// converting values in the same way as Float64Value.
+// The statistics are calculated in a single pass using Welford's algorithm, which is numerically stable.
+// If the iter is empty, all results are 0.
+// This operation will exhaust the iter.
+// Panics if any value is not convertible to a float64.
+func (it *Iter) Stats() (count int, mean, variance, stddev float64) {
+	var m2 float64
+
+	for it.Next() {
+		value := it.Float64Value()
+		count++
+
+		delta := value - mean
+		mean += delta / float64(count)
+		m2 += delta * (value - mean)
+	}
+
+	if count > 0 {
+		variance = m2 / float64(count)
+		stddev = math.Sqrt(variance)
+	}
+
+	return
+}
+
+// ChunkWhileOrdered returns an Iter of []interface{} chunks of the values of this Iter,
+// where a new chunk is started whenever boundary returns true for the previous value and the current value.
+// EG, a boundary of prev > curr splits the values into ascending runs.
+// The first value always starts the first chunk.
+// See BatchUntil.
+func (it *Iter) ChunkWhileOrdered(boundary func(prev, curr interface{}) bool) *Iter {
+	return it.BatchUntil(func(batch []interface{}, next interface{}) bool {
+		return boundary(batch[len(batch)-1], next)
+	})
+}
+
+// ToMap returns a map of all elements, which must be KeyValue instances,
+// where the map key and value types are the same as the types of the given key and value examples.
+// EG, if an int key example and a string value example are passed, a map[int]string is returned.
+// This operation will exhaust the iter.
+// If the iter is empty, an allocated empty map is returned.
+// Panics if either example is nil.
+// Panics if any value is not a KeyValue.
+// Panics if any key or value is not convertible to the type of the corresponding example.
+func (it *Iter) ToMap(keyExample, valExample interface{}) interface{} {
+	if (keyExample == nil) || (valExample == nil) {
+		panic(ErrValueCannotBeNil)
+	}
+
+	var (
+		keyTyp = reflect.TypeOf(keyExample)
+		valTyp = reflect.TypeOf(valExample)
+		result = reflect.MakeMap(reflect.MapOf(keyTyp, valTyp))
+	)
+
+	for it.Next() {
+		kv, isKV := it.Value().(KeyValue)
+		if !isKV {
+			panic(ErrToMapKeyValue)
+		}
+
+		result.SetMapIndex(reflect.ValueOf(kv.Key).Convert(keyTyp), reflect.ValueOf(kv.Value).Convert(valTyp))
+	}
+
+	return result.Interface()
+}
+
+// Duplicates returns an Iter that lazily returns each value of this Iter that occurs more than once.
+// Each such value is returned only once, when it occurs the second time.
+// Panics if a value is not comparable, as it cannot be a map key.
+func (it *Iter) Duplicates() *Iter {
+	seen := map[interface{}]int{}
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for it.Next() {
+			value := it.Value()
+			checkComparable(value, ErrDuplicatesComparable)
+
+			if seen[value]++; seen[value] == 2 {
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
+}
+
+// GroupBy returns a map of each key returned by keyFn to the values of this Iter that produced it, in the order they were read.
+// This operation will exhaust the iter.
+// If the iter is empty, an allocated empty map is returned.
+// Panics if a key is not comparable, as it cannot be a map key.
+func (it *Iter) GroupBy(keyFn func(interface{}) interface{}) map[interface{}][]interface{} {
+	groups := map[interface{}][]interface{}{}
+
+	for it.Next() {
+		value := it.Value()
+		key := keyFn(value)
+		checkComparable(key, ErrGroupByComparable)
+
+		groups[key] = append(groups[key], value)
+	}
+
+	return groups
+}
+
+// StratifiedSample returns a map of each key returned by keyFn to a random sample of at most perKey values of this Iter that produced it.
+// Each sample is selected in a single pass using reservoir sampling, so every value for a key has an equal chance of selection.
+// If a key has perKey or fewer values, they are all selected in the order they were read.
+// Using an rng with a fixed seed produces the same samples every time.
+// This operation will exhaust the iter.
+// If the iter is empty, an allocated empty map is returned.
+// Panics if perKey <= 0.
+// Panics if rng is nil, even if the iter has too few values to need it.
+// Panics if a key is not comparable, as it cannot be a map key.
+func (it *Iter) StratifiedSample(keyFn func(interface{}) interface{}, perKey int, rng *rand.Rand) map[interface{}][]interface{} {
+	if perKey <= 0 {
+		panic(ErrPerKeyGreaterThanZero)
+	}
+
+	if rng == nil {
+		panic(ErrRandCannotBeNil)
+	}
+
+	var (
+		samples = map[interface{}][]interface{}{}
+		seen    = map[interface{}]int{}
+	)
+
+	for it.Next() {
+		value := it.Value()
+		key := keyFn(value)
+		checkComparable(key, ErrStratifiedSampleComparable)
+
+		seen[key]++
+		if sample := samples[key]; len(sample) < perKey {
+			samples[key] = append(sample, value)
+		} else if idx := rng.Intn(seen[key]); idx < perKey {
+			// Replace a random sample value with decreasing probability as more values are seen
+			sample[idx] = value
+		}
+	}
+
+	return samples
+}
+
+// Sorted returns an Iter of the values of this Iter sorted by less, using a stable sort.
+// All values of this Iter are read and sorted on the first call to Next of the returned Iter.
+func (it *Iter) Sorted(less func(a, b interface{}) bool) *Iter {
+	var (
+		sorted []interface{}
+		idx    = -1
+	)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if idx == -1 {
+			sorted = it.ToSlice()
+			sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+			idx = 0
+		}
+
+		if idx == len(sorted) {
+			return nil, false
+		}
+
+		value := sorted[idx]
+		idx++
+		return value, true
+	}, it)
+}
+
+// Min returns the smallest value of this Iter according to less, and true.
+// If there are multiple smallest values, the first one is returned.
+// If the iter is empty, (nil, false) is returned.
+// This operation will exhaust the iter.
+func (it *Iter) Min(less func(a, b interface{}) bool) (interface{}, bool) {
+	var (
+		result  interface{}
+		haveAny bool
+	)
+
+	for it.Next() {
+		if value := it.Value(); !haveAny || less(value, result) {
+			result, haveAny = value, true
+		}
+	}
+
+	return result, haveAny
+}
+
+// Max returns the largest value of this Iter according to less, and true.
+// If there are multiple largest values, the first one is returned.
+// If the iter is empty, (nil, false) is returned.
+// This operation will exhaust the iter.
+func (it *Iter) Max(less func(a, b interface{}) bool) (interface{}, bool) {
+	var (
+		result  interface{}
+		haveAny bool
+	)
+
+	for it.Next() {
+		if value := it.Value(); !haveAny || less(result, value) {
+			result, haveAny = value, true
+		}
+	}
+
+	return result, haveAny
+}
+
+// Grep returns an Iter that lazily returns only the values of this Iter that match re.
+// Values are converted in the same way as StringValue before matching, but are returned unchanged.
+// Panics if re is nil.
+// Panics if any value is not convertible to a string.
+func (it *Iter) Grep(re *regexp.Regexp) *Iter {
+	if re == nil {
+		panic(ErrRegexpCannotBeNil)
+	}
+
+	return it.Filter(func(value interface{}) bool { return re.MatchString(stringOf(value)) })
+}
+
+// GrepV is the inverse of Grep, returning only the values of this Iter that do not match re.
+// Panics if re is nil.
+// Panics if any value is not convertible to a string.
+func (it *Iter) GrepV(re *regexp.Regexp) *Iter {
+	if re == nil {
+		panic(ErrRegexpCannotBeNil)
+	}
+
+	return it.Filter(func(value interface{}) bool { return !re.MatchString(stringOf(value)) })
+}
+
+// SumInt returns the sum of the values of this Iter, converting values in the same way as Int64Value.
+// This operation will exhaust the iter.
+// Panics if any value is not convertible to an int64.
+func (it *Iter) SumInt() int64 {
+	var sum int64
+
+	for it.Next() {
+		sum += it.Int64Value()
+	}
+
+	return sum
+}
+
+// SumFloat returns the sum of the values of this Iter, converting values in the same way as Float64Value.
+// This operation will exhaust the iter.
+// Panics if any value is not convertible to a float64.
+func (it *Iter) SumFloat() float64 {
+	var sum float64
+
+	for it.Next() {
+		sum += it.Float64Value()
+	}
+
+	return sum
+}
+
+// AverageFloat returns the average of the values of this Iter and true, converting values in the same way as Float64Value.
+// If the iter is empty, (0, false) is returned.
+// This operation will exhaust the iter.
+// Panics if any value is not convertible to a float64.
+func (it *Iter) AverageFloat() (float64, bool) {
+	var (
+		sum   float64
+		count int
+	)
+
+	for it.Next() {
+		sum += it.Float64Value()
+		count++
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+
+	return sum / float64(count), true
+}
+
+// Pipe returns the result of calling transform with this Iter.
+// This allows reusable custom stages to be used in a chain of method calls, the same as the built in methods.
+func (it *Iter) Pipe(transform func(in *Iter) *Iter) *Iter {
+	return transform(it)
 }
 
-// NextByteValue retrieves the next value as a byte for cases where you know the iterator has another value.
-// Panics if Next() or ByteValue() panics.
-func (it *Iter) NextByteValue() byte {
-	it.Next()
-	return it.ByteValue()
-}
+// First returns the first value of this Iter for which pred returns true, and true.
+// No further values are read from this Iter once a match is found, so it may be used with an infinite Iter.
+// If no value matches, (nil, false) is returned, and the iter is exhausted.
+func (it *Iter) First(pred func(interface{}) bool) (interface{}, bool) {
+	for it.Next() {
+		if value := it.Value(); pred(value) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// ToArrayOf returns a new array of the same type as the given array prototype, filled with the values of this Iter.
+// EG, if a [3]int is passed, a [3]int is returned.
+// This operation will exhaust the iter.
+// Panics if the prototype is not an array.
+// Panics if the number of values is not the same as the array length.
+// Panics if any value is not convertible to the array element type.
+func (it *Iter) ToArrayOf(arrayPrototype interface{}) interface{} {
+	typ := reflect.TypeOf(arrayPrototype)
+	if (typ == nil) || (typ.Kind() != reflect.Array) {
+		panic(ErrToArrayOfArg)
+	}
+
+	var (
+		elemTyp = typ.Elem()
+		array   = reflect.New(typ).Elem()
+	)
+
+	for i, num := 0, typ.Len(); i < num; i++ {
+		if !it.Next() {
+			panic(ErrToArrayOfTooFewValues)
+		}
+
+		array.Index(i).Set(reflect.ValueOf(it.Value()).Convert(elemTyp))
+	}
+
+	if it.Next() {
+		panic(ErrToArrayOfTooManyValues)
+	}
 
-// RuneValue reads the value and converts it to a rune.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a rune.
-func (it *Iter) RuneValue() rune {
-	return rune(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(rune(0))).Int())
+	return array.Interface()
 }
 
-// NextRuneValue retrieves the next value as a rune for cases where you know the iterator has another value.
-// Panics if Next() or RuneValue() panics.
-func (it *Iter) NextRuneValue() rune {
-	it.Next()
-	return it.RuneValue()
-}
+// AllMatch returns true if pred returns true for every value of this Iter.
+// Stops reading values as soon as pred returns false.
+// Returns true if the iter is empty.
+func (it *Iter) AllMatch(pred func(interface{}) bool) bool {
+	for it.Next() {
+		if !pred(it.Value()) {
+			return false
+		}
+	}
 
-// IntValue reads the value and converts it to an int.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to an int.
-func (it *Iter) IntValue() int {
-	return int(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(0)).Int())
+	return true
 }
 
-// NextIntValue retrieves the next value as an int for cases where you know the iterator has another value.
-// Panics if Next() or IntValue() panics.
-func (it *Iter) NextIntValue() int {
-	it.Next()
-	return it.IntValue()
-}
+// AnyMatch returns true if pred returns true for at least one value of this Iter.
+// Stops reading values as soon as pred returns true.
+// Returns false if the iter is empty.
+func (it *Iter) AnyMatch(pred func(interface{}) bool) bool {
+	for it.Next() {
+		if pred(it.Value()) {
+			return true
+		}
+	}
 
-// Int8Value reads the value and converts it to an int8.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to an int8.
-func (it *Iter) Int8Value() int8 {
-	return int8(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(int8(0))).Int())
+	return false
 }
 
-// NextInt8Value retrieves the next value as an int8 for cases where you know the iterator has another value.
-// Panics if Next() or Int8Value() panics.
-func (it *Iter) NextInt8Value() int8 {
-	it.Next()
-	return it.Int8Value()
+// NoneMatch returns true if pred returns false for every value of this Iter.
+// Stops reading values as soon as pred returns true.
+// Returns true if the iter is empty.
+func (it *Iter) NoneMatch(pred func(interface{}) bool) bool {
+	return !it.AnyMatch(pred)
 }
 
-// Int16Value reads the value and converts it to an int16.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to an int16.
-func (it *Iter) Int16Value() int16 {
-	return int16(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(int16(0))).Int())
-}
+// MapE returns an Iter that lazily applies fn to each value of this Iter.
+// If fn returns an error, the returned Iter stops and Err returns the error, unless SkipErrors is used.
+func (it *Iter) MapE(fn func(interface{}) (interface{}, error)) *Iter {
+	result := NewIterErr(func() (interface{}, bool, error) {
+		if it.Next() {
+			value, err := fn(it.Value())
+			return value, err == nil, err
+		}
 
-// NextInt16Value retrieves the next value as an int16 for cases where you know the iterator has another value.
-// Panics if Next() or Int16Value() panics.
-func (it *Iter) NextInt16Value() int16 {
-	it.Next()
-	return it.Int16Value()
-}
+		return nil, false, nil
+	})
 
-// Int32Value reads the value and converts it to an int32.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to an int32.
-func (it *Iter) Int32Value() int32 {
-	return int32(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(int32(0))).Int())
-}
+	// An error only affects one value, so SkipErrors can continue with the next value
+	result.skippable = true
+	result.closer = it.Close
 
-// NextInt32Value retrieves the next value as an int32 for cases where you know the iterator has another value.
-// Panics if Next() or Int32Value() panics.
-func (it *Iter) NextInt32Value() int32 {
-	it.Next()
-	return it.Int32Value()
+	return result
 }
 
-// Int64Value reads the value and converts it to an int64.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to an int64.
-func (it *Iter) Int64Value() int64 {
-	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(int64(0))).Int()
-}
+// SkipErrors returns an Iter that lazily returns the values of this Iter, skipping any values that fail, rather than stopping.
+// The errors are collected, and can be retrieved with Errors.
+// Only Iters returned by MapE can continue after an error, since an error only affects one value.
+// For any other Iter, if it stops with an error, the error is collected and the returned Iter stops too.
+func (it *Iter) SkipErrors() *Iter {
+	var result *Iter
 
-// NextInt64Value retrieves the next value as an int64 for cases where you know the iterator has another value.
-// Panics if Next() or Int64Value() panics.
-func (it *Iter) NextInt64Value() int64 {
-	it.Next()
-	return it.Int64Value()
-}
+	result = newDerivedIter(func() (interface{}, bool) {
+		// Use Next for an Iter that cannot continue after an error, or to read any unread values
+		if !it.skippable || (len(it.buffer) > 0) {
+			if it.Next() {
+				return it.Value(), true
+			}
 
-// UintValue reads the value and converts it to a uint.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a uint.
-func (it *Iter) UintValue() uint {
-	return uint(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint(0))).Uint())
-}
+			if err := it.Err(); err != nil {
+				result.errs = append(result.errs, err)
+			}
 
-// NextUintValue retrieves the next value as a uint for cases where you know the iterator has another value.
-// Panics if Next() or UintValue() panics.
-func (it *Iter) NextUintValue() uint {
-	it.Next()
-	return it.UintValue()
-}
+			return nil, false
+		}
 
-// Uint8Value reads the value and converts it to a uint8.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a uint8.
-func (it *Iter) Uint8Value() uint8 {
-	return uint8(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint8(0))).Uint())
-}
+		for {
+			value, haveIt, err := it.iterErr()
+			if err == nil {
+				return value, haveIt
+			}
 
-// NextUint8Value retrieves the next value as a uint8 for cases where you know the iterator has another value.
-// Panics if Next() or Uint8Value() panics.
-func (it *Iter) NextUint8Value() uint8 {
-	it.Next()
-	return it.Uint8Value()
-}
+			result.errs = append(result.errs, err)
+		}
+	}, it)
 
-// Uint16Value reads the value and converts it to a uint16.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a uint16.
-func (it *Iter) Uint16Value() uint16 {
-	return uint16(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint16(0))).Uint())
+	return result
 }
 
-// NextUint16Value retrieves the next value as a uint16 for cases where you know the iterator has another value.
-// Panics if Next() or Uint16Value() panics.
-func (it *Iter) NextUint16Value() uint16 {
-	it.Next()
-	return it.Uint16Value()
+// SelfProduct returns an Iter of [2]interface{} pairs of the values of this Iter with each other.
+// Each pair of values at different positions is returned once, in the order they occur: EG, (a, b) but not (b, a).
+// Use SelfProductOrdered to get both (a, b) and (b, a), as one bool cannot also select that.
+// If includeDiagonal is true, each value is also paired with itself: EG, (a, a).
+// All values of this Iter are read on the first call to Next of the returned Iter.
+func (it *Iter) SelfProduct(includeDiagonal bool) *Iter {
+	return it.selfProduct(includeDiagonal, false)
 }
 
-// Uint32Value reads the value and converts it to a uint32.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a uint32.
-func (it *Iter) Uint32Value() uint32 {
-	return uint32(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint32(0))).Uint())
+// SelfProductOrdered is the same as SelfProduct, except that each pair of values at different positions is returned in both orders.
+// EG, for values a, b, the pairs (a, b) and (b, a) are returned.
+func (it *Iter) SelfProductOrdered(includeDiagonal bool) *Iter {
+	return it.selfProduct(includeDiagonal, true)
 }
 
-// NextUint32Value retrieves the next value as a uint32 for cases where you know the iterator has another value.
-// Panics if Next() or Uint32Value() panics.
-func (it *Iter) NextUint32Value() uint32 {
-	it.Next()
-	return it.Uint32Value()
-}
+// selfProduct returns an Iter of pairs of the values of this Iter, where each value is paired with the values from itself onward
+// if ordered is false, or with all values if ordered is true.
+func (it *Iter) selfProduct(includeDiagonal, ordered bool) *Iter {
+	var (
+		values []interface{}
+		i, j   int
+		first  = true
+	)
 
-// Uint64Value reads the value and converts it to a uint64.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a uint64.
-func (it *Iter) Uint64Value() uint64 {
-	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(uint64(0))).Uint()
-}
+	// rowStart returns the column that row i starts at
+	rowStart := func() int {
+		if ordered {
+			return 0
+		}
 
-// NextUint64Value retrieves the next value as a uint64 for cases where you know the iterator has another value.
-// Panics if Next() or Uint64Value() panics.
-func (it *Iter) NextUint64Value() uint64 {
-	it.Next()
-	return it.Uint64Value()
-}
+		return i
+	}
 
-// Float32Value reads the value and converts it to a float32.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a float32.
-func (it *Iter) Float32Value() float32 {
-	return float32(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(float32(0))).Float())
-}
+	return newDerivedIter(func() (interface{}, bool) {
+		if first {
+			first = false
+			values = it.ToSlice()
+		}
 
-// NextFloat32Value retrieves the next value as a float32 for cases where you know the iterator has another value.
-// Panics if Next() or Float32Value() panics.
-func (it *Iter) NextFloat32Value() float32 {
-	it.Next()
-	return it.Float32Value()
-}
+		for i < len(values) {
+			// Move to the next row when the current row is finished
+			if j == len(values) {
+				i++
+				j = rowStart()
+				continue
+			}
 
-// Float64Value reads the value and converts it to a float64.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a float64.
-func (it *Iter) Float64Value() float64 {
-	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(float64(0))).Float()
-}
+			if (i == j) && !includeDiagonal {
+				j++
+				continue
+			}
 
-// NextFloat64Value retrieves the next value as a float64 for cases where you know the iterator has another value.
-// Panics if Next() or Float64Value() panics.
-func (it *Iter) NextFloat64Value() float64 {
-	it.Next()
-	return it.Float64Value()
-}
+			pair := [2]interface{}{values[i], values[j]}
+			j++
+			return pair, true
+		}
 
-// Complex64Value reads the value and converts it to a complex64.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a complex64.
-func (it *Iter) Complex64Value() complex64 {
-	return complex64(reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(complex64(0))).Complex())
+		return nil, false
+	}, it)
 }
 
-// NextComplex64Value retrieves the next value as a complex64 for cases where you know the iterator has another value.
-// Panics if Next() or Complex64Value() panics.
-func (it *Iter) NextComplex64Value() complex64 {
-	it.Next()
-	return it.Complex64Value()
+// ToChannel sends each value of this Iter to the given channel, then closes it.
+// This operation will exhaust the iter.
+func (it *Iter) ToChannel(ch chan<- interface{}) {
+	for it.Next() {
+		ch <- it.Value()
+	}
+
+	close(ch)
 }
 
-// Complex128Value reads the value and converts it to a complex128.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a complex128.
-func (it *Iter) Complex128Value() complex128 {
-	return reflect.ValueOf(it.Value()).Convert(reflect.TypeOf(complex128(0))).Complex()
+// ToChannelAsync returns a channel with the given buffer size, and starts a goroutine that calls ToChannel with it.
+// The caller must receive all values until the channel is closed, otherwise the goroutine will leak.
+func (it *Iter) ToChannelAsync(buffer int) <-chan interface{} {
+	ch := make(chan interface{}, buffer)
+	go it.ToChannel(ch)
+
+	return ch
 }
 
-// NextComplex128Value retrieves the next value as a complex128 for cases where you know the iterator has another value.
-// Panics if Next() or Complex128Value() panics.
-func (it *Iter) NextComplex128Value() complex128 {
-	it.Next()
-	return it.Complex128Value()
+// MapChunks returns an Iter that lazily reads chunks of up to size values of this Iter, applies fn to each chunk,
+// and returns the values of each resulting slice.
+// Only one chunk is read at a time, and the last chunk may have fewer than size values.
+// Panics if size = 0.
+func (it *Iter) MapChunks(size uint, fn func([]interface{}) []interface{}) *Iter {
+	if size == 0 {
+		panic(ErrSizeGreaterThanZero)
+	}
+
+	var (
+		mapped []interface{}
+		done   bool
+	)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for len(mapped) == 0 {
+			if done {
+				return nil, false
+			}
+
+			// Read next chunk
+			chunk := make([]interface{}, 0, size)
+			for uint(len(chunk)) < size {
+				if !it.Next() {
+					done = true
+					break
+				}
+
+				chunk = append(chunk, it.Value())
+			}
+
+			if len(chunk) > 0 {
+				mapped = fn(chunk)
+			}
+		}
+
+		value := mapped[0]
+		mapped = mapped[1:]
+		return value, true
+	}, it)
+}
+
+// DecaySum returns an Iter that lazily returns a running float64 sum of the values of this Iter,
+// where each value is weighted by 0.5^(age/halfLife), and age is the number of values read since it.
+// EG, with a halfLife of 2, the current value has full weight, the value before it has weight 0.707, the value before that has weight 0.5, etc.
+// The sum is calculated incrementally by decaying the previous sum by 0.5^(1/halfLife) and adding the current value.
+// Values are converted in the same way as Float64Value.
+// Panics if halfLife <= 0.
+// Panics if any value is not convertible to a float64.
+func (it *Iter) DecaySum(halfLife int) *Iter {
+	if halfLife <= 0 {
+		panic(ErrHalfLifeGreaterThanZero)
+	}
+
+	var (
+		decay = math.Pow(0.5, 1/float64(halfLife))
+		sum   float64
+	)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if it.Next() {
+			sum = sum*decay + it.Float64Value()
+			return sum, true
+		}
+
+		return nil, false
+	}, it)
 }
 
-// StringValue reads the value and converts it to a string.
-// Panics if Value() method panics.
-// Panics if the value is not convertible to a string.
-func (it *Iter) StringValue() string {
-	return fmt.Sprintf("%s", reflect.ValueOf(it.Value()).Convert(reflect.TypeOf("")))
+// SplitOnIndent returns an Iter that lazily returns a KeyValue{Key: indent level, Value: line} for each line of this Iter,
+// where the indent level is the number of leading spaces and tabs.
+// See SplitOnIndentTabWidth.
+func (it *Iter) SplitOnIndent() *Iter {
+	return it.SplitOnIndentTabWidth(1)
 }
 
-// NextStringValue retrieves the next value as a string for cases where you know the iterator has another value.
-// Panics if Next() or StringValue() panics.
-func (it *Iter) NextStringValue() string {
-	it.Next()
-	return it.StringValue()
+// SplitOnIndentTabWidth is the same as SplitOnIndent, except each leading tab counts as tabWidth towards the indent level.
+// Lines are converted in the same way as StringValue, and returned unchanged.
+// Panics if tabWidth <= 0.
+// Panics if any value is not convertible to a string.
+func (it *Iter) SplitOnIndentTabWidth(tabWidth int) *Iter {
+	if tabWidth <= 0 {
+		panic(ErrTabWidthGreaterThanZero)
+	}
+
+	return it.Map(func(value interface{}) interface{} {
+		var (
+			line   = stringOf(value)
+			indent int
+		)
+
+	INDENT:
+		for _, char := range line {
+			switch char {
+			case ' ':
+				indent++
+			case '\t':
+				indent += tabWidth
+			default:
+				break INDENT
+			}
+		}
+
+		return KeyValue{Key: indent, Value: line}
+	})
 }
 
-// Unread places the given value at the end of an internal buffer of unread values.
-// It is up to the caller to unread correctly.
-// Example:
-// - the source has values 1,2,3
-// - values 1,2 have been iterated, 3 has not
-// - caller can choose to unread 2, so that Next/Value returns 2 from buffer without consulting source
-// - calling Next again consults source to read 3
-// - caller can unread 3,2,1, so that Next/Value returns 1,2,3 without consulting source
-// - calling Next again returns false
-// There is nothing preventing the caller from reading 1,2,3 and unreading 1,2,3 causing Next/Value to return 3,2,1.
-// Panics if the iterator is exhausted.
-func (it *Iter) Unread(val interface{}) {
-	// Die if iterator already exhausted
-	if it.iter == nil {
-		panic(ErrUnreadExhaustedIter)
+// SpillToFile writes each value of this Iter to the file at the given path, and returns an Iter that reads them back.
+// Each value is converted to bytes by marshal, and written preceded by its length, so the file can be read back any number of times with OfSpillFile.
+// The returned Iter is the result of OfSpillFile, so it returns the []byte records, which must be unmarshalled by the caller.
+// This operation will exhaust the iter.
+// If the file cannot be written, or marshal fails, the error is returned.
+func (it *Iter) SpillToFile(path string, marshal func(interface{}) ([]byte, error)) (*Iter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(file)
+
+	for it.Next() {
+		record, err := marshal(it.Value())
+		if err == nil {
+			if err = binary.Write(writer, binary.BigEndian, uint64(len(record))); err == nil {
+				_, err = writer.Write(record)
+			}
+		}
+
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, err
 	}
 
-	it.buffer = append(it.buffer, val)
+	return OfSpillFile(path)
+}
+
+// InFlight is a value returned by an Iter from Iter.RateLimitConcurrency.
+// Release must be called once the value has been processed, to allow another value to be read.
+// Calling Release more than once has no further effect.
+type InFlight struct {
+	Value   interface{}
+	Release func()
 }
 
-// SplitIntoRows splits the iterator into rows of at most the number of columns specified.
-// Since the number of items to iterate is not known, the algorithm fills across the first row from left to right,
-// then fills across the second row, and so on.
-// The original ordering is retained by iterating each row from left to right.
-// If the number of items <= the number of columns, a single row is returned.
-// This operation will exhaust the iter.
-// Panics if the iter has already been exhausted.
-// Panics if cols = 0.
-func (it *Iter) SplitIntoRows(cols uint) [][]interface{} {
-	if cols == 0 {
-		panic(ErrColsGreaterThanZero)
+// RateLimitConcurrency returns an Iter that lazily returns each value of this Iter as an InFlight,
+// where no more than maxInFlight values can be in flight (returned and not yet released) at once.
+// Next blocks until a value has been released, to provide backpressure when values are processed in separate goroutines.
+// Panics if maxInFlight <= 0.
+func (it *Iter) RateLimitConcurrency(maxInFlight int) *Iter {
+	if maxInFlight <= 0 {
+		panic(ErrMaxInFlightGreaterThanZero)
 	}
 
+	sem := make(chan struct{}, maxInFlight)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		// Acquire a slot before reading, so no value is read until it can be processed
+		sem <- struct{}{}
+
+		if !it.Next() {
+			<-sem
+			return nil, false
+		}
+
+		var once sync.Once
+		return InFlight{
+			Value:   it.Value(),
+			Release: func() { once.Do(func() { <-sem }) },
+		}, true
+	}, it)
+}
+
+// Unzip returns the keys and values of all elements, which must be KeyValue instances, as two slices of the same length.
+// This is the inverse of Zip.
+// This operation will exhaust the iter.
+// If the iter is empty, two allocated empty slices are returned.
+// Panics if any value is not a KeyValue.
+func (it *Iter) Unzip() ([]interface{}, []interface{}) {
 	var (
-		split = [][]interface{}{}
-		row   = make([]interface{}, 0, cols)
-		idx   uint
+		keys   = []interface{}{}
+		values = []interface{}{}
 	)
 
 	for it.Next() {
-		row = append(row, it.Value())
-		idx++
-
-		if idx == cols {
-			split = append(split, row)
-			row = make([]interface{}, 0, cols)
-			idx = 0
+		kv, isKV := it.Value().(KeyValue)
+		if !isKV {
+			panic(ErrUnzipKeyValue)
 		}
-	}
 
-	// If len == 0, must be a corner case: no items, or an exact multiple of n items.
-	// Otherwise, row contains a partial slice of the last < n items.
-	if len(row) > 0 {
-		split = append(split, row)
+		keys = append(keys, kv.Key)
+		values = append(values, kv.Value)
 	}
 
-	return split
+	return keys, values
 }
 
-// SplitIntoRowsOf is a version of SplitIntoRows where the slice type is the same as the type of the given value.
-// EG, if a value of type int is passed, a [][]int is returned.
-// This operation will exhaust the iter.
-// Panics if the iter has already been exhausted.
-// Panics if cols = 0.
-// Panics is value is nil.
-// Panics if any value is not convertible to the type of the given value.
-func (it *Iter) SplitIntoRowsOf(cols uint, value interface{}) interface{} {
-	if cols == 0 {
-		panic(ErrColsGreaterThanZero)
-	}
+// WithContext returns an Iter that lazily returns the values of this Iter until the context is done.
+// The context is checked before each value is read, and once it is done the returned Iter stops and Err returns the context error.
+// A read that is already blocked (EG, receiving from a channel) is not interrupted.
+func (it *Iter) WithContext(ctx context.Context) *Iter {
+	var result *Iter
 
-	if value == nil {
-		panic(ErrValueCannotBeNil)
-	}
+	result = newDerivedIter(func() (interface{}, bool) {
+		if err := ctx.Err(); err != nil {
+			result.err = err
+			return nil, false
+		}
+
+		if it.Next() {
+			return it.Value(), true
+		}
+
+		return nil, false
+	}, it)
 
+	return result
+}
+
+// OrElse returns an Iter that lazily returns the values of this Iter,
+// unless this Iter is empty or stops with an Err, in which case the values of fallback are returned afterwards.
+// No values are read from fallback unless it is needed.
+// A nil fallback is treated as an empty Iter.
+func (it *Iter) OrElse(fallback *Iter) *Iter {
 	var (
-		intCols = int(cols)
-		typ     = reflect.TypeOf(value)
-		split   = reflect.MakeSlice(reflect.SliceOf(reflect.SliceOf(typ)), 0, 0)
-		row     = reflect.MakeSlice(reflect.SliceOf(typ), 0, intCols)
-		idx     uint
+		haveAny     bool
+		useFallback bool
+	)
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if !useFallback {
+			if it.Next() {
+				haveAny = true
+				return it.Value(), true
+			}
+
+			if haveAny && (it.Err() == nil) {
+				return nil, false
+			}
+
+			useFallback = true
+		}
+
+		if (fallback != nil) && fallback.Next() {
+			return fallback.Value(), true
+		}
+
+		return nil, false
+	}, it, fallback)
+}
+
+// CommonPrefix returns the longest common prefix of the string values of this Iter.
+// The prefix never ends in the middle of a multibyte rune.
+// Reading stops as soon as the prefix becomes empty, otherwise the iter is exhausted.
+// An empty Iter returns "".
+// Panics if any value read is not a string.
+func (it *Iter) CommonPrefix() string {
+	var (
+		prefix string
+		first  = true
 	)
 
 	for it.Next() {
-		row = reflect.Append(row, reflect.ValueOf(it.Value()).Convert(typ))
-		idx++
+		str, isa := it.Value().(string)
+		if !isa {
+			panic(ErrCommonPrefixString)
+		}
 
-		if idx == cols {
-			split = reflect.Append(split, row)
-			row = reflect.MakeSlice(reflect.SliceOf(typ), 0, intCols)
-			idx = 0
+		if first {
+			prefix = str
+			first = false
+		} else {
+			n := 0
+			for (n < len(prefix)) && (n < len(str)) && (prefix[n] == str[n]) {
+				n++
+			}
+
+			// Back up to the start of a rune that differs
+			for (n > 0) && (n < len(prefix)) && !utf8.RuneStart(prefix[n]) {
+				n--
+			}
+
+			prefix = prefix[:n]
 		}
-	}
 
-	// If len == 0, must be a corner case: no items, or an exact multiple of n items.
-	// Otherwise, row contains a partial slice of the last < n items.
-	if row.Len() > 0 {
-		split = reflect.Append(split, row)
+		if prefix == "" {
+			break
+		}
 	}
 
-	return split.Interface()
+	return prefix
 }
 
-// SplitIntoColumns splits the iterator into columns with at most the number of rows specified.
-// The algorithm reads all the items into a slice first to determine the number of them and ensures that each row has the same number of columns, except for a remainder spread across one or more rows.
-// EG, if 23 items exist and rows = 5, 23 / 5 = 4 r 3, so the first 3 rows have 5 items (4 + 1 from remainder), the last 2 have 4: 3 * 5 + 2 * 4 = 15 + 8 = 23.
-// If the number of items <= the number of rows, then the number of rows = number of items, 1 item per row.
-// This method is simply the transpose operation of SplitIntoRows.
-// This operation will exhaust the iter.
-// Panics if the iter has already been exhausted.
-// Panics if rows = 0.
-func (it *Iter) SplitIntoColumns(rows uint) [][]interface{} {
-	if rows == 0 {
-		panic(ErrRowsGreaterThanZero)
-	}
+// Peaks returns an Iter that lazily returns the values of this Iter that are strict local maxima,
+// that is values that are greater than both the value before and after them, according to less.
+// The first and last values are never peaks, since they only have one neighbor.
+func (it *Iter) Peaks(less func(a, b interface{}) bool) *Iter {
+	return it.localExtrema(func(prev, cur, next interface{}) bool { return less(prev, cur) && less(next, cur) })
+}
 
-	// Collect values into a slice first
+// Valleys returns an Iter that lazily returns the values of this Iter that are strict local minima,
+// that is values that are less than both the value before and after them, according to less.
+// The first and last values are never valleys, since they only have one neighbor.
+func (it *Iter) Valleys(less func(a, b interface{}) bool) *Iter {
+	return it.localExtrema(func(prev, cur, next interface{}) bool { return less(cur, prev) && less(cur, next) })
+}
+
+// localExtrema returns an Iter that lazily returns the values of this Iter that have a value before and after them,
+// and for which isExtremum returns true.
+func (it *Iter) localExtrema(isExtremum func(prev, cur, next interface{}) bool) *Iter {
 	var (
-		values         = it.ToSlice()
-		numValues      = len(values)
-		numRows        = int(rows)
-		numItems, rmdr = numValues / numRows, numValues % numRows
-		start, end     int
-		split          = [][]interface{}{}
+		prev, cur interface{}
+		first     = true
 	)
 
-	if numValues < numRows {
-		// Fewer items than requested number of rows, actual number of rows = number of items
-		numRows = numValues
+	return newDerivedIter(func() (interface{}, bool) {
+		if first {
+			first = false
 
-		// Each row has 1 item, no remainder
-		numItems, rmdr = 1, 0
-	}
+			if !it.Next() {
+				return nil, false
+			}
+			prev = it.Value()
 
-	for i := 0; i < numRows; i++ {
-		// start, end = indexes for a subslice of values for this row
-		end = start + numItems
-		if rmdr > 0 {
-			// Add one extra item from remainder
-			end++
-			rmdr--
+			if !it.Next() {
+				return nil, false
+			}
+			cur = it.Value()
 		}
-		split = append(split, values[start:end])
 
-		// next row start index is current row end index (start is inclusive, end is exclusive)
-		start = end
-	}
+		for it.Next() {
+			next := it.Value()
+			found := isExtremum(prev, cur, next)
+			value := cur
+			prev, cur = cur, next
 
-	return split
+			if found {
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
 }
 
-// SplitIntoColumnsOf is a version of SplitIntoColumns where the slice type is the same as the type of the given value.
-// This operation will exhaust the iter.
-// Panics if the iter has already been exhausted.
-// Panics if rows = 0.
-// Panics if value is nil.
-// Panics if any value is not convertible to the type of the given value.
-func (it *Iter) SplitIntoColumnsOf(rows uint, value interface{}) interface{} {
-	if rows == 0 {
-		panic(ErrRowsGreaterThanZero)
-	}
+// ToGob writes each value of this Iter to w as a separate gob encoded interface{} value, which can be read back with OfGob.
+// Any concrete types other than the basic types gob already knows must be registered with gob.Register.
+// This operation will exhaust the iter, unless encoding fails.
+// If a value cannot be encoded, the error is returned.
+func (it *Iter) ToGob(w io.Writer) error {
+	encoder := gob.NewEncoder(w)
 
-	if value == nil {
-		panic(ErrValueCannotBeNil)
+	for it.Next() {
+		value := it.Value()
+		if err := encoder.Encode(&value); err != nil {
+			return err
+		}
 	}
 
-	// Collect values into a slice first
-	var (
-		values         = it.ToSlice()
-		numValues      = len(values)
-		numRows        = int(rows)
-		numItems, rmdr = numValues / numRows, numValues % numRows
-		start, end     int
-		typ            = reflect.TypeOf(value)
-	)
+	return nil
+}
 
-	if numValues < numRows {
-		// Fewer items than requested number of rows, actual number of rows = number of items
-		numRows = numValues
+// Enumerate returns an Iter that lazily returns the values of this Iter as KeyValue instances,
+// where the Key is the int index of the value starting at 0, and the Value is the value.
+func (it *Iter) Enumerate() *Iter {
+	index := 0
 
-		// Each row has 1 item, no remainder
-		numItems, rmdr = 1, 0
+	return newDerivedIter(func() (interface{}, bool) {
+		if it.Next() {
+			kv := KeyValue{Key: index, Value: it.Value()}
+			index++
+			return kv, true
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Window returns an Iter that lazily returns overlapping windows of size values of this Iter as []interface{},
+// where each window starts one value after the previous window.
+// EG, Window(2) of 1, 2, 3 returns [1, 2], [2, 3].
+// If this Iter has fewer than size values, the returned Iter is empty.
+// Each window is a new slice, so it is safe to keep windows.
+// Panics if size = 0.
+func (it *Iter) Window(size uint) *Iter {
+	if size == 0 {
+		panic(ErrSizeGreaterThanZero)
 	}
 
-	// Allocate number of rows now we know for sure how many there are
-	split := reflect.MakeSlice(reflect.SliceOf(reflect.SliceOf(typ)), numRows, numRows)
+	var window []interface{}
 
-	for i := 0; i < numRows; i++ {
-		// start, end = indexes for a subslice of values for this row
-		end = start + numItems
-		if rmdr > 0 {
-			// Add one extra item from remainder
-			end++
-			rmdr--
+	return newDerivedIter(func() (interface{}, bool) {
+		if window == nil {
+			// Fill the first window
+			window = make([]interface{}, 0, size)
+			for (uint(len(window)) < size) && it.Next() {
+				window = append(window, it.Value())
+			}
+
+			if uint(len(window)) < size {
+				return nil, false
+			}
+
+			return window, true
 		}
 
-		row := reflect.MakeSlice(reflect.SliceOf(typ), end-start, end-start)
-		for j, colIdx := start, 0; j < end; j, colIdx = j+1, colIdx+1 {
-			row.Index(colIdx).Set(reflect.ValueOf(values[j]).Convert(typ))
+		if !it.Next() {
+			return nil, false
 		}
-		split.Index(i).Set(row)
 
-		// next row start index is current row end index (start is inclusive, end is exclusive)
-		start = end
+		next := make([]interface{}, size)
+		copy(next, window[1:])
+		next[size-1] = it.Value()
+		window = next
+
+		return window, true
+	}, it)
+}
+
+// Chunk returns an Iter that lazily returns the values of this Iter in []interface{} chunks of size values,
+// where the last chunk may have fewer values.
+// Only one chunk of values is read at a time, unlike SplitIntoRows which reads all values.
+// Panics if size = 0.
+func (it *Iter) Chunk(size uint) *Iter {
+	if size == 0 {
+		panic(ErrSizeGreaterThanZero)
 	}
 
-	return split.Interface()
+	var done bool
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if done {
+			return nil, false
+		}
+
+		chunk := make([]interface{}, 0, size)
+		for uint(len(chunk)) < size {
+			if !it.Next() {
+				done = true
+				break
+			}
+
+			chunk = append(chunk, it.Value())
+		}
+
+		if len(chunk) == 0 {
+			return nil, false
+		}
+
+		return chunk, true
+	}, it)
 }
 
-// ToSlice collects the elements into a slice
-func (it *Iter) ToSlice() []interface{} {
-	slice := []interface{}{}
+// Partition separates the values of this Iter into those that match the predicate and those that do not, preserving order.
+// Both slices are non-nil, even if they are empty.
+// This operation will exhaust the iter.
+func (it *Iter) Partition(pred func(interface{}) bool) (matched, unmatched []interface{}) {
+	matched, unmatched = []interface{}{}, []interface{}{}
 
 	for it.Next() {
-		slice = append(slice, it.Value())
+		if value := it.Value(); pred(value) {
+			matched = append(matched, value)
+		} else {
+			unmatched = append(unmatched, value)
+		}
 	}
 
-	return slice
+	return
 }
 
-// ToSliceOf returns a slice of all elements, where the slice type is the same as the type of the given value.
-// EG, if a value of type int is passed, a []int is returned.
-// Panics if value is nil.
-// Panics if any value is not convertible to the type of the given value.
-func (it *Iter) ToSliceOf(value interface{}) interface{} {
-	if value == nil {
-		panic(ErrValueCannotBeNil)
+// Flatten returns an Iter that lazily returns the values of this Iter, where any value that is an *Iter, Iterable, or *Iterable
+// is replaced by its values, recursively. A nil *Iter or *Iterable is replaced by nothing.
+// Nesting is handled with a stack rather than recursion, so any depth of nesting can be flattened.
+// Other values, including arrays, slices, and maps, are returned as is.
+func (it *Iter) Flatten() *Iter {
+	stack := []*Iter{it}
+
+	return newDerivedIter(func() (interface{}, bool) {
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if !top.Next() {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			switch value := top.Value().(type) {
+			case *Iter:
+				if value != nil {
+					stack = append(stack, value)
+				}
+			case Iterable:
+				stack = append(stack, value.Iter())
+			case *Iterable:
+				if value != nil {
+					stack = append(stack, value.Iter())
+				}
+			default:
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
+}
+
+// ToMapBy returns a map of all elements, where the key of each element is the result of keyFn.
+// If more than one element has the same key, the last such element is in the map.
+// This operation will exhaust the iter.
+// If the iter is empty, an allocated empty map is returned.
+// Panics if a key is not comparable.
+func (it *Iter) ToMapBy(keyFn func(interface{}) interface{}) map[interface{}]interface{} {
+	result := map[interface{}]interface{}{}
+
+	for it.Next() {
+		value := it.Value()
+		key := keyFn(value)
+		checkComparable(key, ErrToMapByComparable)
+
+		result[key] = value
 	}
 
+	return result
+}
+
+// Append returns an Iter that lazily returns the values of this Iter, followed by the items given.
+// The items are only returned once this Iter is exhausted.
+func (it *Iter) Append(items ...interface{}) *Iter {
+	return Concat(it, Of(items...))
+}
+
+// Dedup returns an Iter that lazily returns each value of this Iter that is not equal to the previous value, using ==.
+// Unlike Distinct, a value that occurs again later after a different value is returned again.
+// Panics if a value is not comparable.
+func (it *Iter) Dedup() *Iter {
 	var (
-		typ   = reflect.TypeOf(value)
-		slice = reflect.MakeSlice(reflect.SliceOf(typ), 0, 0)
+		prev  interface{}
+		first = true
 	)
 
-	for it.Next() {
-		slice = reflect.Append(slice, reflect.ValueOf(it.Value()).Convert(typ))
-	}
+	return newDerivedIter(func() (interface{}, bool) {
+		for it.Next() {
+			value := it.Value()
+			checkComparable(value, ErrDedupComparable)
 
-	return slice.Interface()
+			if first || (value != prev) {
+				first = false
+				prev = value
+				return value, true
+			}
+		}
+
+		return nil, false
+	}, it)
+}
+
+// Scan returns an Iter that lazily returns each intermediate result of applying fn to an accumulator and each value of this Iter,
+// where the accumulator starts as initial, and then becomes each result.
+// The initial value is not returned, so there is one result for each value.
+// EG, if the values are 1, 2, 3, initial is 0, and fn adds, then 1, 3, 6 are returned.
+func (it *Iter) Scan(initial interface{}, fn func(acc, val interface{}) interface{}) *Iter {
+	acc := initial
+
+	return newDerivedIter(func() (interface{}, bool) {
+		if it.Next() {
+			acc = fn(acc, it.Value())
+			return acc, true
+		}
+
+		return nil, false
+	}, it)
 }
 
 // ==== Iterable
@@ -3,10 +3,25 @@
 package goiter
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
@@ -327,6 +342,76 @@ func TestReaderIterFuncAndOfReader(t *testing.T) {
 	assert.False(t, iter.Next())
 }
 
+func TestReaderIterErrFuncAndOfReaderErr(t *testing.T) {
+	var (
+		errRead  = fmt.Errorf("connection reset")
+		iterFunc = ReaderIterErrFunc(io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(errRead)))
+		val      interface{}
+		next     bool
+		err      error
+	)
+
+	for _, abyte := range []byte("ab") {
+		val, next, err = iterFunc()
+		assert.Equal(t, abyte, val)
+		assert.True(t, next)
+		assert.Nil(t, err)
+	}
+
+	_, next, err = iterFunc()
+	assert.False(t, next)
+	assert.Equal(t, errRead, err)
+
+	// Error after two bytes
+	iter, errFunc := OfReaderErr(io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(errRead)))
+	assert.Nil(t, errFunc())
+	assert.Equal(t, []interface{}{byte('a'), byte('b')}, iter.ToSlice())
+	assert.Equal(t, errRead, errFunc())
+	assert.Equal(t, errRead, iter.Err())
+
+	// Clean eof
+	iter, errFunc = OfReaderErr(strings.NewReader("ab"))
+	assert.Equal(t, []interface{}{byte('a'), byte('b')}, iter.ToSlice())
+	assert.Nil(t, errFunc())
+}
+
+func TestOfReaderBuffered(t *testing.T) {
+	str := "the quick brown fox"
+
+	for _, bufSize := range []int{1, 4, 4096} {
+		assert.Equal(t, OfReader(strings.NewReader(str)).ToSlice(), OfReaderBuffered(strings.NewReader(str), bufSize).ToSlice())
+	}
+
+	assert.Equal(t, []interface{}{}, OfReaderBuffered(strings.NewReader(""), 16).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrBufSizeGreaterThanZero, recover())
+		}()
+
+		OfReaderBuffered(strings.NewReader(str), 0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func BenchmarkOfReader(b *testing.B) {
+	data := make([]byte, 1024*1024)
+
+	for i := 0; i < b.N; i++ {
+		for iter := OfReader(bytes.NewReader(data)); iter.Next(); {
+		}
+	}
+}
+
+func BenchmarkOfReaderBuffered(b *testing.B) {
+	data := make([]byte, 1024*1024)
+
+	for i := 0; i < b.N; i++ {
+		for iter := OfReaderBuffered(bytes.NewReader(data), 4096); iter.Next(); {
+		}
+	}
+}
+
 func TestReaderToRunesIterFuncAndOfReaderRunes(t *testing.T) {
 	inputs := []string{
 		"",
@@ -385,6 +470,73 @@ func TestReaderToRunesIterFuncAndOfReaderRunes(t *testing.T) {
 	}
 }
 
+func TestOfReaderRunesSkipBOM(t *testing.T) {
+	assert.Equal(t, []interface{}{'a', 'b'}, OfReaderRunesSkipBOM(strings.NewReader("\xef\xbb\xbfab")).ToSlice())
+	assert.Equal(t, []interface{}{'a', 'b'}, OfReaderRunesSkipBOM(strings.NewReader("ab")).ToSlice())
+	assert.Equal(t, []interface{}{}, OfReaderRunesSkipBOM(strings.NewReader("\xef\xbb\xbf")).ToSlice())
+	assert.Equal(t, []interface{}{}, OfReaderRunesSkipBOM(strings.NewReader("")).ToSlice())
+
+	// Only a leading BOM is skipped
+	assert.Equal(t, []interface{}{'a', '\uFEFF', 'b'}, OfReaderRunesSkipBOM(strings.NewReader("a\uFEFFb")).ToSlice())
+	assert.Equal(t, []interface{}{'\uFEFF'}, OfReaderRunesSkipBOM(strings.NewReader("\uFEFF\uFEFF")).ToSlice())
+}
+
+func TestReaderToRunesEncodingIterFuncAndOfReaderRunesEncoding(t *testing.T) {
+	var (
+		str       = "héllo😀"
+		encodeLE  bytes.Buffer
+		encodeBE  bytes.Buffer
+		strAsRune = []interface{}{}
+	)
+
+	for _, char := range str {
+		strAsRune = append(strAsRune, char)
+	}
+
+	for _, unit := range utf16.Encode([]rune(str)) {
+		binary.Write(&encodeLE, binary.LittleEndian, unit)
+		binary.Write(&encodeBE, binary.BigEndian, unit)
+	}
+
+	// The emoji requires a surrogate pair
+	assert.Equal(t, 14, encodeLE.Len())
+
+	assert.Equal(t, strAsRune, OfReaderRunesEncoding(strings.NewReader(str), UTF8).ToSlice())
+	assert.Equal(t, strAsRune, OfReaderRunesEncoding(bytes.NewReader(encodeLE.Bytes()), UTF16LE).ToSlice())
+	assert.Equal(t, strAsRune, OfReaderRunesEncoding(bytes.NewReader(encodeBE.Bytes()), UTF16BE).ToSlice())
+
+	// Exhausted
+	iterFunc := ReaderToRunesEncodingIterFunc(bytes.NewReader([]byte{'a', 0}), UTF16LE)
+	val, next := iterFunc()
+	assert.Equal(t, 'a', val)
+	assert.True(t, next)
+
+	for i := 0; i < 2; i++ {
+		val, next = iterFunc()
+		assert.Equal(t, utf8.RuneError, val)
+		assert.False(t, next)
+	}
+
+	// Unpaired high surrogate followed by a valid char, lone low surrogate, and trailing odd byte
+	assert.Equal(
+		t,
+		[]interface{}{utf8.RuneError, 'a', utf8.RuneError, utf8.RuneError},
+		OfReaderRunesEncoding(bytes.NewReader([]byte{0x3d, 0xd8, 'a', 0, 0, 0xde, 'b'}), UTF16LE).ToSlice(),
+	)
+
+	// Unpaired high surrogate at end
+	assert.Equal(t, []interface{}{utf8.RuneError}, OfReaderRunesEncoding(bytes.NewReader([]byte{0xd8, 0x3d}), UTF16BE).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrInvalidRuneEncoding, recover())
+		}()
+
+		OfReaderRunesEncoding(strings.NewReader(str), RuneEncoding(3))
+		assert.Fail(t, "Must panic")
+	}()
+}
+
 func TestReaderToLinesIterFuncAndOfReaderLines(t *testing.T) {
 	var (
 		inputs = []string{
@@ -432,6 +584,73 @@ func TestReaderToLinesIterFuncAndOfReaderLines(t *testing.T) {
 	}
 }
 
+func TestReaderToLinesIterFuncDelimAndOfReaderLinesDelim(t *testing.T) {
+	for _, delim := range []string{"\x1e", "||"} {
+		for _, input := range []string{
+			"",
+			"one",
+			"one" + delim + "two",
+			"one" + delim + "two" + delim,
+			"one" + delim + delim + "three",
+			"a|b\nc" + delim + "𝆑",
+		} {
+			var (
+				iterFunc = ReaderToLinesIterFuncDelim(strings.NewReader(input), delim)
+				lines    = []interface{}{}
+			)
+
+			if input != "" {
+				for _, line := range strings.Split(strings.TrimSuffix(input, delim), delim) {
+					lines = append(lines, line)
+				}
+			}
+
+			for _, line := range lines {
+				val, next := iterFunc()
+				assert.Equal(t, line, val)
+				assert.True(t, next)
+			}
+
+			val, next := iterFunc()
+			assert.Equal(t, "", val)
+			assert.False(t, next)
+
+			assert.Equal(t, lines, OfReaderLinesDelim(strings.NewReader(input), delim).ToSlice())
+		}
+	}
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrDelimCannotBeEmpty, recover())
+		}()
+
+		ReaderToLinesIterFuncDelim(strings.NewReader("a"), "")
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestReaderToWordsIterFuncAndOfReaderWords(t *testing.T) {
+	iterFunc := ReaderToWordsIterFunc(strings.NewReader("  hello   world\tfoo\n"))
+
+	for _, word := range []string{"hello", "world", "foo"} {
+		val, next := iterFunc()
+		assert.Equal(t, word, val)
+		assert.True(t, next)
+	}
+
+	val, next := iterFunc()
+	assert.Equal(t, "", val)
+	assert.False(t, next)
+
+	val, next = iterFunc()
+	assert.Equal(t, "", val)
+	assert.False(t, next)
+
+	assert.Equal(t, []interface{}{"hello", "world", "foo"}, OfReaderWords(strings.NewReader("  hello   world\tfoo\n")).ToSlice())
+	assert.Equal(t, []interface{}{}, OfReaderWords(strings.NewReader("")).ToSlice())
+	assert.Equal(t, []interface{}{}, OfReaderWords(strings.NewReader(" \t\n ")).ToSlice())
+}
+
 func TestFlattenArraySlice(t *testing.T) {
 	f := FlattenArraySlice([2]int{1, 2})
 	assert.Equal(t, []interface{}{1, 2}, f)
@@ -446,6 +665,38 @@ func TestFlattenArraySlice(t *testing.T) {
 	assert.Equal(t, []interface{}{1, 2, 3, "4", "5", "6", "7", "8"}, f)
 }
 
+func TestFlattenArraySliceDepth(t *testing.T) {
+	assert.Equal(t, []interface{}{[]int{1, 2}, []int{3}}, FlattenArraySliceDepth([][]int{{1, 2}, {3}}, 1))
+	assert.Equal(t, []interface{}{1, 2, 3}, FlattenArraySliceDepth([][]int{{1, 2}, {3}}, 2))
+	assert.Equal(t, []interface{}{1, 2, 3}, FlattenArraySliceDepth([][]int{{1, 2}, {3}}, -1))
+
+	value := []interface{}{1, [][]int{{2}, {3, 4}}, []string{"5"}}
+	assert.Equal(t, []interface{}{1, [][]int{{2}, {3, 4}}, []string{"5"}}, FlattenArraySliceDepth(value, 1))
+	assert.Equal(t, []interface{}{1, []int{2}, []int{3, 4}, "5"}, FlattenArraySliceDepth(value, 2))
+	assert.Equal(t, []interface{}{1, 2, 3, 4, "5"}, FlattenArraySliceDepth(value, 3))
+	assert.Equal(t, FlattenArraySlice(value), FlattenArraySliceDepth(value, -1))
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrFlattenArraySliceDepthArg, recover())
+		}()
+
+		FlattenArraySliceDepth(1, 1)
+		assert.Fail(t, "Must panic")
+	}()
+
+	for _, maxDepth := range []int{0, -2} {
+		func() {
+			defer func() {
+				assert.Equal(t, ErrMaxDepthGreaterThanZero, recover())
+			}()
+
+			FlattenArraySliceDepth([]int{1}, maxDepth)
+			assert.Fail(t, "Must panic")
+		}()
+	}
+}
+
 func TestFlattenArraySliceAsType(t *testing.T) {
 	f := FlattenArraySliceAsType([2]int{1, 2}, 0)
 	assert.Equal(t, []int{1, 2}, f)
@@ -494,6 +745,138 @@ func TestNewIter(t *testing.T) {
 	}
 }
 
+func TestNewIterErr(t *testing.T) {
+	var (
+		errRead = fmt.Errorf("read failed")
+		reader  = io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(errRead))
+		buf     = make([]byte, 1)
+		iter    = NewIterErr(func() (interface{}, bool, error) {
+			if _, err := reader.Read(buf); err != nil {
+				if err == io.EOF {
+					return nil, false, nil
+				}
+
+				return nil, false, err
+			}
+
+			return buf[0], true, nil
+		})
+	)
+
+	assert.Equal(t, []interface{}{byte('a'), byte('b')}, iter.ToSlice())
+	assert.Equal(t, errRead, iter.Err())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNewIterNeedsIterator, recover())
+		}()
+
+		NewIterErr(nil)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestTryNext(t *testing.T) {
+	var (
+		errRead = fmt.Errorf("read failed")
+		reader  = io.MultiReader(strings.NewReader("a"), iotest.ErrReader(errRead))
+		buf     = make([]byte, 1)
+		iter    = NewIterErr(func() (interface{}, bool, error) {
+			_, err := reader.Read(buf)
+			return buf[0], err == nil, err
+		})
+	)
+
+	// Error mid stream
+	value, haveIt, err := iter.TryNext()
+	assert.Equal(t, byte('a'), value)
+	assert.True(t, haveIt)
+	assert.Nil(t, err)
+
+	value, haveIt, err = iter.TryNext()
+	assert.Nil(t, value)
+	assert.False(t, haveIt)
+	assert.Equal(t, errRead, err)
+
+	// Exhausted
+	value, haveIt, err = iter.TryNext()
+	assert.Nil(t, value)
+	assert.False(t, haveIt)
+	assert.Equal(t, ErrNextExhaustedIter, err.Error())
+
+	// Iter that cannot fail
+	iter = Of(1)
+
+	value, haveIt, err = iter.TryNext()
+	assert.Equal(t, 1, value)
+	assert.True(t, haveIt)
+	assert.Nil(t, err)
+
+	value, haveIt, err = iter.TryNext()
+	assert.Nil(t, value)
+	assert.False(t, haveIt)
+	assert.Nil(t, err)
+
+	_, _, err = iter.TryNext()
+	assert.Equal(t, ErrNextExhaustedIter, err.Error())
+}
+
+func TestNewIterErrContract(t *testing.T) {
+	// Each Iter that can fail returns its values, then stops with the error, and is then exhausted
+	assertStopsWithErr := func(iter *Iter, expected ...interface{}) {
+		var values []interface{}
+		for {
+			value, haveIt, err := iter.TryNext()
+			if !haveIt {
+				assert.NotNil(t, err)
+				assert.Equal(t, iter.Err(), err)
+				break
+			}
+
+			values = append(values, value)
+		}
+
+		assert.Equal(t, expected, values)
+
+		_, haveIt, err := iter.TryNext()
+		assert.False(t, haveIt)
+		assert.Equal(t, ErrNextExhaustedIter, err.Error())
+	}
+
+	errRead := fmt.Errorf("connection reset")
+	assertStopsWithErr(NewIterErr(ReaderIterErrFunc(io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(errRead)))), byte('a'), byte('b'))
+	assertStopsWithErr(OfCSVReader(strings.NewReader("a,b\nc\ne,f\n")), []string{"a", "b"})
+	assertStopsWithErr(OfJSONArray(strings.NewReader(`[1, {"a" 2}, 3]`)), 1.0)
+	assertStopsWithErr(OfJSONLines(strings.NewReader("{\"id\" 2}\n{\"id\": 3}\n")))
+
+	var buf bytes.Buffer
+	assert.Nil(t, Of(1, 2).ToGob(&buf))
+	assertStopsWithErr(OfGob(bytes.NewReader(buf.Bytes()[:buf.Len()-1])), 1)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	assert.Nil(t, os.WriteFile(path, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 'a'}, 0600))
+
+	iter, err := OfDirEntries(path)
+	assert.Nil(t, err)
+	assertStopsWithErr(iter)
+
+	iter, err = OfSpillFile(path)
+	assert.Nil(t, err)
+	assertStopsWithErr(iter)
+
+	// A function that has returned an error returns (invalid, false, nil) on every later call
+	iterFunc := ReaderIterErrFunc(iotest.ErrReader(errRead))
+	_, _, err = iterFunc()
+	assert.Equal(t, errRead, err)
+
+	for i := 0; i < 2; i++ {
+		_, haveIt, err := iterFunc()
+		assert.False(t, haveIt)
+		assert.Nil(t, err)
+	}
+}
+
 func TestOf(t *testing.T) {
 	// Empty items
 	iter := Of()
@@ -566,6 +949,46 @@ func TestOfFlatten(t *testing.T) {
 	assert.False(t, iter.Next())
 }
 
+func TestOfFlattenAsType(t *testing.T) {
+	assert.Equal(t, []interface{}{1, 2, 3, 4, 5}, OfFlattenAsType([][]int{{1, 2}, {3, 4, 5}}, 0).ToSlice())
+	assert.Equal(
+		t,
+		[]interface{}{1, 2, 3, 4, 5, 6},
+		OfFlattenAsType([]interface{}{1, [2]int{2, 3}, [][]uint{{4}, {}, {5, 6}}}, 0).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{}, OfFlattenAsType([][]int{}, 0).ToSlice())
+
+	// Lazy: the inconvertible value is never reached
+	assert.Equal(t, []interface{}{1, 2}, OfFlattenAsType([]interface{}{[]int{1, 2}, []interface{}{3, "x"}}, 0).Limit(2).ToSlice())
+
+	func() {
+		defer func() {
+			assert.NotNil(t, recover())
+		}()
+
+		OfFlattenAsType([]interface{}{1, "x"}, 0).ToSlice()
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrOfFlattenAsTypeArg, recover())
+		}()
+
+		OfFlattenAsType(1, 0)
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrValueCannotBeNil, recover())
+		}()
+
+		OfFlattenAsType([]int{1}, nil)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
 func TestOfElements(t *testing.T) {
 	// ==== Array
 
@@ -635,87 +1058,555 @@ func TestOfElements(t *testing.T) {
 	assert.False(t, next)
 }
 
-func TestValueOfType(t *testing.T) {
-	var (
-		v1   = "1"
-		v2   = "2"
-		iter = Of(v1, v2)
+func TestOfMapInOrder(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+
+	assert.Equal(
+		t,
+		[]interface{}{KeyValue{"c", 3}, KeyValue{"a", 1}, KeyValue{"b", 2}},
+		OfMapInOrder(m, []string{"c", "x", "a", "b"}).ToSlice(),
 	)
+	assert.Equal(t, []interface{}{KeyValue{"b", 2}}, OfMapInOrder(m, [1]string{"b"}).ToSlice())
+	assert.Equal(t, []interface{}{}, OfMapInOrder(m, []string{}).ToSlice())
 
-	next := iter.Next()
-	assert.True(t, next)
-	var v string = iter.ValueOfType("").(string)
-	assert.Equal(t, v1, v)
-	v = iter.NextValueOfType("").(string)
-	assert.Equal(t, v2, v)
+	func() {
+		defer func() {
+			assert.Equal(t, ErrOfMapInOrderMapArg, recover())
+		}()
+
+		OfMapInOrder([]int{}, []int{})
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrOfMapInOrderKeysArg, recover())
+		}()
+
+		OfMapInOrder(m, []int{})
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrOfMapInOrderKeysArg, recover())
+		}()
+
+		OfMapInOrder(m, "a")
+		assert.Fail(t, "Must panic")
+	}()
 }
 
-func TestBoolValue(t *testing.T) {
+func TestOfMapSorted(t *testing.T) {
 	var (
-		iter = Of(true, false)
+		m    = map[int]string{3: "c", 1: "a", 2: "b", 5: "e", 4: "d"}
+		less = func(a, b interface{}) bool { return a.(int) < b.(int) }
 	)
 
-	next := iter.Next()
-	assert.True(t, next)
-	var v bool = iter.BoolValue()
-	assert.True(t, v)
+	assert.Equal(
+		t,
+		[]interface{}{KeyValue{1, "a"}, KeyValue{2, "b"}, KeyValue{3, "c"}, KeyValue{4, "d"}, KeyValue{5, "e"}},
+		OfMapSorted(m, less).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{}, OfMapSorted(map[int]string{}, less).ToSlice())
 
-	v = iter.NextBoolValue()
-	assert.False(t, v)
+	func() {
+		defer func() {
+			assert.Equal(t, ErrOfMapSortedArg, recover())
+		}()
+
+		OfMapSorted([]int{1}, less)
+		assert.Fail(t, "Must panic")
+	}()
 }
 
-func TestIntValue(t *testing.T) {
-	{
-		var (
-			v1   = byte(1)
-			v2   = byte(2)
-			iter = Of(v1, v2)
-		)
+func TestOfChannel(t *testing.T) {
+	ch := make(chan interface{})
+	go func() {
+		for i := 1; i <= 3; i++ {
+			ch <- i
+		}
 
-		next := iter.Next()
-		assert.True(t, next)
-		var v byte = iter.ByteValue()
-		assert.Equal(t, v1, v)
-		v = iter.NextByteValue()
-		assert.Equal(t, v2, v)
-	}
+		close(ch)
+	}()
 
-	{
-		var (
-			v1   = '1'
-			v2   = '2'
-			iter = Of(v1, v2)
-		)
+	assert.Equal(t, []interface{}{1, 2, 3}, OfChannel(ch).ToSlice())
 
-		next := iter.Next()
-		assert.True(t, next)
-		var v rune = iter.RuneValue()
-		assert.Equal(t, v1, v)
-		v = iter.NextRuneValue()
-		assert.Equal(t, v2, v)
-	}
+	// Already closed
+	ch = make(chan interface{})
+	close(ch)
+	assert.False(t, OfChannel(ch).Next())
+}
 
-	{
-		var (
-			v1   = 1
-			v2   = 2
-			iter = Of(v1, v2)
-		)
+func TestOfRange(t *testing.T) {
+	assert.Equal(t, []interface{}{0, 1, 2, 3, 4}, OfRange(0, 5, 1).ToSlice())
+	assert.Equal(t, []interface{}{0, 3}, OfRange(0, 5, 3).ToSlice())
+	assert.Equal(t, []interface{}{10, 8, 6, 4, 2}, OfRange(10, 0, -2).ToSlice())
+	assert.Equal(t, []interface{}{}, OfRange(5, 5, 1).ToSlice())
+	assert.Equal(t, []interface{}{}, OfRange(5, 0, 1).ToSlice())
+	assert.Equal(t, []interface{}{}, OfRange(0, 5, -1).ToSlice())
+
+	// No overflow at the int boundaries
+	assert.Equal(t, []interface{}{math.MaxInt - 1}, OfRange(math.MaxInt-1, math.MaxInt, 5).ToSlice())
+	assert.Equal(t, []interface{}{math.MaxInt - 10, math.MaxInt - 5}, OfRange(math.MaxInt-10, math.MaxInt, 5).ToSlice())
+	assert.Equal(t, []interface{}{math.MinInt + 1}, OfRange(math.MinInt+1, math.MinInt, -5).ToSlice())
+	assert.Equal(t, []interface{}{math.MinInt + 10, math.MinInt + 5}, OfRange(math.MinInt+10, math.MinInt, -5).ToSlice())
+	assert.Equal(t, []interface{}{math.MinInt, -1, math.MaxInt - 1}, OfRange(math.MinInt, math.MaxInt, math.MaxInt).ToSlice())
+	assert.Equal(t, []interface{}{math.MaxInt, -1}, OfRange(math.MaxInt, math.MinInt, math.MinInt).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrStepCannotBeZero, recover())
+		}()
 
-		next := iter.Next()
-		assert.True(t, next)
-		var v int = iter.IntValue()
-		assert.Equal(t, v1, v)
-		v = iter.NextIntValue()
-		assert.Equal(t, v2, v)
-	}
+		OfRange(0, 5, 0)
+		assert.Fail(t, "Must panic")
+	}()
+}
 
-	{
-		var (
-			v1   = int8(1)
-			v2   = int8(2)
-			iter = Of(v1, v2)
-		)
+func TestGenerate(t *testing.T) {
+	var (
+		n       = 0
+		counter = func() interface{} {
+			n++
+			return n
+		}
+	)
+
+	assert.Equal(t, []interface{}{1, 2, 3}, Generate(counter).Limit(3).ToSlice())
+
+	n = 0
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, Generate(counter).TakeWhile(func(value interface{}) bool { return value.(int) < 5 }).ToSlice())
+	assert.Equal(t, 5, n)
+}
+
+func TestOfCSVReader(t *testing.T) {
+	iter := OfCSVReader(strings.NewReader("name,age\nalice,30\n\"bob, jr\",25\n"))
+	assert.Equal(t, []interface{}{[]string{"name", "age"}, []string{"alice", "30"}, []string{"bob, jr", "25"}}, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+
+	iter = OfCSVReaderComma(strings.NewReader("name\tage\nalice\t30\n"), '\t')
+	assert.Equal(t, []interface{}{[]string{"name", "age"}, []string{"alice", "30"}}, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+
+	assert.Equal(t, []interface{}{}, OfCSVReader(strings.NewReader("")).ToSlice())
+
+	// Malformed record
+	iter = OfCSVReader(strings.NewReader("a,b\nc,\"d\n"))
+	assert.Equal(t, []interface{}{[]string{"a", "b"}}, iter.ToSlice())
+	assert.NotNil(t, iter.Err())
+}
+
+func TestOfJSONArray(t *testing.T) {
+	iter := OfJSONArray(strings.NewReader(`[{"a": 1, "b": [true, null]}, 2, "three", 4.5]`))
+	assert.Equal(
+		t,
+		[]interface{}{map[string]interface{}{"a": 1.0, "b": []interface{}{true, nil}}, 2.0, "three", 4.5},
+		iter.ToSlice(),
+	)
+	assert.Nil(t, iter.Err())
+
+	iter = OfJSONArray(strings.NewReader(" [ ] "))
+	assert.Equal(t, []interface{}{}, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+
+	// Not an array
+	iter = OfJSONArray(strings.NewReader(`{"a": 1}`))
+	assert.Equal(t, []interface{}{}, iter.ToSlice())
+	assert.Equal(t, ErrOfJSONArrayNotArray, iter.Err().Error())
+
+	// Empty reader
+	iter = OfJSONArray(strings.NewReader(""))
+	assert.Equal(t, []interface{}{}, iter.ToSlice())
+	assert.Equal(t, io.EOF, iter.Err())
+
+	// Malformed element
+	iter = OfJSONArray(strings.NewReader(`[1, {"a" 2}]`))
+	assert.Equal(t, []interface{}{1.0}, iter.ToSlice())
+	assert.NotNil(t, iter.Err())
+
+	// Unterminated array
+	iter = OfJSONArray(strings.NewReader(`[1, 2`))
+	assert.Equal(t, []interface{}{1.0, 2.0}, iter.ToSlice())
+	assert.NotNil(t, iter.Err())
+}
+
+func TestOfJSONLines(t *testing.T) {
+	iter := OfJSONLines(strings.NewReader("{\"id\": 1}\n{\"id\": 2, \"tags\": [\"a\"]}\n\n  \n{\"id\": 3}\n"))
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{"id": 1.0},
+			map[string]interface{}{"id": 2.0, "tags": []interface{}{"a"}},
+			map[string]interface{}{"id": 3.0},
+		},
+		iter.ToSlice(),
+	)
+	assert.Nil(t, iter.Err())
+
+	// Last line need not be terminated
+	assert.Equal(t, []interface{}{1.0, "two"}, OfJSONLines(strings.NewReader("1\r\n\"two\"")).ToSlice())
+	assert.Equal(t, []interface{}{}, OfJSONLines(strings.NewReader("")).ToSlice())
+
+	// Malformed line
+	iter = OfJSONLines(strings.NewReader("{\"id\": 1}\n{\"id\" 2}\n{\"id\": 3}\n"))
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": 1.0}}, iter.ToSlice())
+	assert.NotNil(t, iter.Err())
+}
+
+func TestOfDirEntries(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0600))
+	}
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "sub"), 0700))
+
+	// Read in small batches
+	batchSize := dirEntriesBatchSize
+	dirEntriesBatchSize = 2
+	defer func() { dirEntriesBatchSize = batchSize }()
+
+	iter, err := OfDirEntries(dir)
+	assert.Nil(t, err)
+
+	var (
+		names = []string{}
+		dirs  = []string{}
+	)
+
+	for iter.Next() {
+		entry := iter.Value().(os.DirEntry)
+		names = append(names, entry.Name())
+
+		if entry.IsDir() {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt", "sub"}, names)
+	assert.Equal(t, []string{"sub"}, dirs)
+	assert.Nil(t, iter.Err())
+
+	// Empty directory
+	iter, err = OfDirEntries(filepath.Join(dir, "sub"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{}, iter.ToSlice())
+
+	// Directory cannot be opened
+	iter, err = OfDirEntries(filepath.Join(dir, "missing"))
+	assert.Nil(t, iter)
+	assert.True(t, os.IsNotExist(err))
+
+	// Not a directory
+	iter, err = OfDirEntries(filepath.Join(dir, "a.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{}, iter.ToSlice())
+	assert.NotNil(t, iter.Err())
+
+	// Close an abandoned iter
+	iter, err = OfDirEntries(dir)
+	assert.Nil(t, err)
+	assert.True(t, iter.Next())
+	iter.Close()
+	assert.False(t, iter.Next())
+}
+
+func TestOfWalk(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/b/c", "d", "e"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, dir), 0700))
+	}
+
+	for _, file := range []string{"1", "a/2", "a/b/3", "a/b/c/4", "d/5"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(root, file), []byte(file), 0600))
+	}
+
+	// A symbolic link loop is returned but not followed
+	assert.Nil(t, os.Symlink(root, filepath.Join(root, "a", "loop")))
+
+	expected := []interface{}{}
+	for _, file := range []string{"1", "a/2", "a/b/3", "a/b/c/4", "a/loop", "d/5"} {
+		expected = append(expected, filepath.Join(root, filepath.FromSlash(file)))
+	}
+
+	iter := OfWalk(root)
+	assert.Equal(t, expected, iter.ToSlice())
+	assert.Nil(t, iter.Errors())
+
+	// Same order as filepath.WalkDir
+	walked := []interface{}{}
+	filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if !entry.IsDir() {
+			walked = append(walked, path)
+		}
+		return nil
+	})
+	assert.Equal(t, walked, expected)
+
+	// A directory that cannot be read is skipped, and it is only read when the walk reaches it
+	iter = OfWalk(root)
+	assert.Equal(t, expected[0], iter.NextValue())
+	assert.Nil(t, os.RemoveAll(filepath.Join(root, "a")))
+	assert.Equal(t, []interface{}{expected[5]}, iter.ToSlice())
+	assert.Equal(t, 1, len(iter.Errors()))
+	assert.True(t, os.IsNotExist(iter.Errors()[0]))
+
+	// Root is a file
+	file := filepath.Join(root, "1")
+	assert.Equal(t, []interface{}{file}, OfWalk(file).ToSlice())
+
+	// Root does not exist
+	iter = OfWalk(filepath.Join(root, "missing"))
+	assert.Equal(t, []interface{}{}, iter.ToSlice())
+	assert.Equal(t, 1, len(iter.Errors()))
+	assert.True(t, os.IsNotExist(iter.Errors()[0]))
+}
+
+func TestIterate(t *testing.T) {
+	double := func(value interface{}) interface{} { return value.(int) * 2 }
+	assert.Equal(t, []interface{}{1, 2, 4, 8}, Iterate(1, double).Limit(4).ToSlice())
+
+	// Fibonacci with a pair seed
+	fib := Iterate([2]int{0, 1}, func(value interface{}) interface{} {
+		pair := value.([2]int)
+		return [2]int{pair[1], pair[0] + pair[1]}
+	}).Map(func(value interface{}) interface{} { return value.([2]int)[0] })
+	assert.Equal(t, []interface{}{0, 1, 1, 2, 3, 5, 8}, fib.Limit(7).ToSlice())
+
+	// fn is not called before the seed is returned
+	assert.Equal(t, []interface{}{"seed"}, Iterate("seed", func(interface{}) interface{} {
+		assert.Fail(t, "Must not be called")
+		return nil
+	}).Limit(1).ToSlice())
+}
+
+func TestRepeatAndCycle(t *testing.T) {
+	assert.Equal(t, []interface{}{"x", "x", "x"}, Repeat("x", 3).ToSlice())
+	assert.Equal(t, []interface{}{}, Repeat("x", 0).ToSlice())
+	assert.Equal(t, []interface{}{"x", "x", "x", "x"}, Repeat("x", -1).Limit(4).ToSlice())
+
+	assert.Equal(t, []interface{}{1, 2, 1, 2, 1}, Cycle(1, 2).Limit(5).ToSlice())
+	assert.Equal(t, []interface{}{1, 1}, Cycle(1).Limit(2).ToSlice())
+	assert.Equal(t, []interface{}{}, Cycle().ToSlice())
+}
+
+func TestOfSyncMap(t *testing.T) {
+	var m sync.Map
+	m.Store("a", 1)
+	m.Store("b", 2)
+
+	iter := OfSyncMap(&m)
+
+	// Changes after construction are not seen
+	m.Store("c", 3)
+
+	assert.ElementsMatch(t, []interface{}{KeyValue{"a", 1}, KeyValue{"b", 2}}, iter.ToSlice())
+	assert.Equal(t, []interface{}{}, OfSyncMap(&sync.Map{}).ToSlice())
+	assert.Equal(t, []interface{}{}, OfSyncMap(nil).ToSlice())
+}
+
+func TestZip(t *testing.T) {
+	assert.Equal(t, []interface{}{KeyValue{1, "a"}, KeyValue{2, "b"}}, Zip(Of(1, 2, 3), Of("a", "b")).ToSlice())
+
+	// a exhausted first leaves b unread
+	b := Of("a", "b")
+	assert.Equal(t, []interface{}{KeyValue{1, "a"}}, Zip(Of(1), b).ToSlice())
+	assert.Equal(t, "b", b.NextValue())
+
+	assert.Equal(t, []interface{}{}, Zip(Of(), Of(1)).ToSlice())
+	assert.Equal(t, []interface{}{}, Zip(Of(1), Of()).ToSlice())
+}
+
+func TestConcat(t *testing.T) {
+	assert.Equal(t, []interface{}{1, 2, 3}, Concat(Of(1, 2), nil, Of(), Of(3)).ToSlice())
+	assert.Equal(t, []interface{}{}, Concat().ToSlice())
+
+	// Later iters are not read until earlier iters are exhausted
+	var (
+		pulled = false
+		second = NewIter(func() (interface{}, bool) {
+			pulled = true
+			return nil, false
+		})
+		iter = Concat(Of(1), second)
+	)
+
+	assert.Equal(t, 1, iter.NextValue())
+	assert.False(t, pulled)
+	assert.False(t, iter.Next())
+	assert.True(t, pulled)
+}
+
+func TestRoundRobinLongest(t *testing.T) {
+	assert.Equal(
+		t,
+		[]interface{}{1, "a", 2, "-", 3, "-"},
+		RoundRobinLongest("-", Of(1, 2, 3), Of("a")).ToSlice(),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{"-", 1, "-", "-", 2, "-"},
+		RoundRobinLongest("-", Of(), Of(1, 2), nil).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{}, RoundRobinLongest("-", Of(), nil).ToSlice())
+	assert.Equal(t, []interface{}{}, RoundRobinLongest("-").ToSlice())
+}
+
+func TestPollWithBackoff(t *testing.T) {
+	type fetchResult struct {
+		value  interface{}
+		haveIt bool
+		err    error
+	}
+
+	var (
+		errDone = fmt.Errorf("done")
+		script  = []fetchResult{
+			{nil, false, nil},
+			{nil, false, nil},
+			{nil, false, nil},
+			{1, true, nil},
+			{nil, false, nil},
+			{2, true, nil},
+			{3, true, nil},
+			{nil, false, errDone},
+		}
+		fetch = func() (interface{}, bool, error) {
+			result := script[0]
+			script = script[1:]
+			return result.value, result.haveIt, result.err
+		}
+		sleeps = []time.Duration{}
+		sleep  = func(d time.Duration) { sleeps = append(sleeps, d) }
+	)
+
+	iter := PollWithBackoffSleep(fetch, time.Second, 3*time.Second, sleep)
+	assert.Equal(t, []interface{}{1, 2, 3}, iter.ToSlice())
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, time.Second}, sleeps)
+	assert.Equal(t, errDone, iter.Err())
+
+	// Real clock
+	iter = PollWithBackoff(func() (interface{}, bool, error) { return 1, true, nil }, time.Millisecond, time.Millisecond)
+	assert.Equal(t, []interface{}{1, 1}, iter.Limit(2).ToSlice())
+}
+
+func TestZipN(t *testing.T) {
+	assert.Equal(
+		t,
+		[]interface{}{[]interface{}{1, "a", 1.0}, []interface{}{2, "b", 2.0}},
+		ZipN(Of(1, 2, 3), Of("a", "b"), Of(1.0, 2.0, 3.0, 4.0)).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{[]interface{}{1}}, ZipN(Of(1)).ToSlice())
+	assert.Equal(t, []interface{}{}, ZipN(Of(1), nil).ToSlice())
+	assert.Equal(t, []interface{}{}, ZipN().ToSlice())
+}
+
+func TestNextValue(t *testing.T) {
+	iter := Of(1, 2)
+	assert.Equal(t, 1, iter.NextValue())
+	assert.Equal(t, 2, iter.NextValue())
+
+	// Panics if there is no next value
+	func() {
+		defer func() {
+			assert.Equal(t, ErrValueExhaustedIter, recover())
+		}()
+
+		iter.NextValue()
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		iter.NextValue()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestValueOfType(t *testing.T) {
+	var (
+		v1   = "1"
+		v2   = "2"
+		iter = Of(v1, v2)
+	)
+
+	next := iter.Next()
+	assert.True(t, next)
+	var v string = iter.ValueOfType("").(string)
+	assert.Equal(t, v1, v)
+	v = iter.NextValueOfType("").(string)
+	assert.Equal(t, v2, v)
+}
+
+func TestBoolValue(t *testing.T) {
+	var (
+		iter = Of(true, false)
+	)
+
+	next := iter.Next()
+	assert.True(t, next)
+	var v bool = iter.BoolValue()
+	assert.True(t, v)
+
+	v = iter.NextBoolValue()
+	assert.False(t, v)
+}
+
+func TestIntValue(t *testing.T) {
+	{
+		var (
+			v1   = byte(1)
+			v2   = byte(2)
+			iter = Of(v1, v2)
+		)
+
+		next := iter.Next()
+		assert.True(t, next)
+		var v byte = iter.ByteValue()
+		assert.Equal(t, v1, v)
+		v = iter.NextByteValue()
+		assert.Equal(t, v2, v)
+	}
+
+	{
+		var (
+			v1   = '1'
+			v2   = '2'
+			iter = Of(v1, v2)
+		)
+
+		next := iter.Next()
+		assert.True(t, next)
+		var v rune = iter.RuneValue()
+		assert.Equal(t, v1, v)
+		v = iter.NextRuneValue()
+		assert.Equal(t, v2, v)
+	}
+
+	{
+		var (
+			v1   = 1
+			v2   = 2
+			iter = Of(v1, v2)
+		)
+
+		next := iter.Next()
+		assert.True(t, next)
+		var v int = iter.IntValue()
+		assert.Equal(t, v1, v)
+		v = iter.NextIntValue()
+		assert.Equal(t, v2, v)
+	}
+
+	{
+		var (
+			v1   = int8(1)
+			v2   = int8(2)
+			iter = Of(v1, v2)
+		)
 
 		next := iter.Next()
 		assert.True(t, next)
@@ -1448,6 +2339,1636 @@ func TestForLoop(t *testing.T) {
 	}
 }
 
+func TestBatchUntil(t *testing.T) {
+	// Flush when the batch sum would exceed 5
+	sumExceeds5 := func(batch []interface{}, next interface{}) bool {
+		sum := next.(int)
+		for _, value := range batch {
+			sum += value.(int)
+		}
+
+		return sum > 5
+	}
+
+	iter := Of(1, 2, 3, 4, 5).BatchUntil(sumExceeds5)
+	assert.Equal(t, []interface{}{[]interface{}{1, 2}, []interface{}{3}, []interface{}{4}, []interface{}{5}}, iter.ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		iter.Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Final partial batch is flushed
+	iter = Of(1, 1, 1, 4).BatchUntil(sumExceeds5)
+	assert.Equal(t, []interface{}{[]interface{}{1, 1, 1}, []interface{}{4}}, iter.ToSlice())
+
+	// Empty source
+	assert.Equal(t, []interface{}{}, Of().BatchUntil(sumExceeds5).ToSlice())
+}
+
+func TestSkipEvery(t *testing.T) {
+	assert.Equal(t, []interface{}{1, 2, 4, 5}, Of(1, 2, 3, 4, 5, 6).SkipEvery(3).ToSlice())
+	assert.Equal(t, []interface{}{1, 2, 4}, Of(1, 2, 3, 4).SkipEvery(3).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1, 2, 3).SkipEvery(1).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().SkipEvery(2).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNGreaterThanZero, recover())
+		}()
+
+		Of().SkipEvery(0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestMap(t *testing.T) {
+	double := func(value interface{}) interface{} { return value.(int) * 2 }
+
+	iter := Of(1, 2, 3).Map(double)
+	assert.Equal(t, 2, iter.NextValue())
+	assert.Equal(t, 4, iter.NextValue())
+	assert.Equal(t, 6, iter.NextValue())
+	assert.False(t, iter.Next())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		iter.Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Chained
+	assert.Equal(t, []interface{}{4, 8, 12}, Of(1, 2, 3).Map(double).Map(double).ToSlice())
+
+	// After a Filter
+	even := func(value interface{}) bool { return value.(int)%2 == 0 }
+
+	iter = Of(1, 2, 3, 4).Filter(even).Map(double)
+	assert.Equal(t, []interface{}{4, 8}, iter.ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, "Iter.Next called on exhausted iterator", recover())
+		}()
+
+		iter.Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Empty source
+	assert.False(t, Of().Map(double).Next())
+}
+
+func TestNeighbors(t *testing.T) {
+	iter := Of(1, 2, 3).Neighbors()
+	assert.Equal(t, [3]interface{}{nil, 1, 2}, iter.NextValue())
+	assert.Equal(t, [3]interface{}{1, 2, 3}, iter.NextValue())
+	assert.Equal(t, [3]interface{}{2, 3, nil}, iter.NextValue())
+	assert.False(t, iter.Next())
+
+	assert.Equal(t, []interface{}{[3]interface{}{nil, 1, nil}}, Of(1).Neighbors().ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Neighbors().ToSlice())
+}
+
+func TestFilter(t *testing.T) {
+	isEven := func(value interface{}) bool { return value.(int)%2 == 0 }
+
+	iter := Of(1, 2, 3, 4, 5, 6).Filter(isEven)
+	assert.Equal(t, []interface{}{2, 4, 6}, iter.ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		iter.Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Last values filtered away
+	assert.Equal(t, []interface{}{2}, Of(1, 2, 3, 5).Filter(isEven).ToSlice())
+
+	// Remove everything
+	iter = Of(1, 2, 3).Filter(func(interface{}) bool { return false })
+	assert.False(t, iter.Next())
+
+	// Chained with Map
+	double := func(value interface{}) interface{} { return value.(int) * 2 }
+	assert.Equal(t, []interface{}{2, 4, 6}, Of(1, 2, 3).Map(double).Filter(isEven).ToSlice())
+	assert.Equal(t, []interface{}{4, 8}, Of(1, 2, 3, 4).Filter(isEven).Map(double).ToSlice())
+}
+
+func TestTextReader(t *testing.T) {
+	var (
+		values = []string{"one", "two", "three"}
+		src    = Of("one", "two", "three")
+		buf    = make([]byte, 2)
+		str    strings.Builder
+	)
+
+	// Read in small pieces to ensure values are split across reads correctly
+	reader := src.TextReader(", ")
+	for {
+		n, err := reader.Read(buf)
+		str.Write(buf[:n])
+		if err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+	}
+	assert.Equal(t, strings.Join(values, ", "), str.String())
+
+	// Reading again still returns EOF
+	n, err := reader.Read(buf)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, io.EOF, err)
+
+	// Read all at once, matching Join of the same values
+	for _, sep := range []string{"", ", "} {
+		text, err := io.ReadAll(Of("a", "", "bc").TextReader(sep))
+		assert.Nil(t, err)
+		assert.Equal(t, Of("a", "", "bc").Join(sep), string(text))
+	}
+
+	// Empty source
+	text, err := io.ReadAll(Of().TextReader(","))
+	assert.Nil(t, err)
+	assert.Equal(t, Of().Join(","), string(text))
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, "a, , bc", Of("a", "", "bc").Join(", "))
+	assert.Equal(t, "ab", Of("a", "b").Join(""))
+	assert.Equal(t, "a", Of("a").Join(", "))
+	assert.Equal(t, "", Of().Join(", "))
+}
+
+func TestBatchTimeOrCount(t *testing.T) {
+	var (
+		start  = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		timeFn = func(value interface{}) time.Time { return start.Add(time.Duration(value.(int)) * time.Second) }
+	)
+
+	// Count limit triggers first
+	iter := Of(0, 1, 2, 3, 4).BatchTimeOrCount(2, time.Minute, timeFn)
+	assert.Equal(t, []interface{}{[]interface{}{0, 1}, []interface{}{2, 3}, []interface{}{4}}, iter.ToSlice())
+
+	// Age limit triggers first
+	iter = Of(0, 1, 5, 6, 20).BatchTimeOrCount(10, 3*time.Second, timeFn)
+	assert.Equal(t, []interface{}{[]interface{}{0, 1}, []interface{}{5, 6}, []interface{}{20}}, iter.ToSlice())
+
+	// Span of exactly maxAge does not end the batch
+	iter = Of(0, 3).BatchTimeOrCount(10, 3*time.Second, timeFn)
+	assert.Equal(t, []interface{}{[]interface{}{0, 3}}, iter.ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrMaxCountGreaterThanZero, recover())
+		}()
+
+		Of().BatchTimeOrCount(0, time.Second, timeFn)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestForEach(t *testing.T) {
+	var (
+		values = []interface{}{}
+		iter   = Of(1, 2, 3)
+	)
+
+	iter.ForEach(func(value interface{}) { values = append(values, value) })
+	assert.Equal(t, []interface{}{1, 2, 3}, values)
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		iter.Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Empty source
+	Of().ForEach(func(interface{}) { assert.Fail(t, "Must not be called") })
+}
+
+func TestFilterRuns(t *testing.T) {
+	assert.Equal(t, []interface{}{"b", "b", "b"}, Of("a", "b", "b", "b", "c").FilterRuns(2).ToSlice())
+	assert.Equal(t, []interface{}{1, 1, 3, 3}, Of(1, 1, 2, 3, 3).FilterRuns(2).ToSlice())
+	assert.Equal(t, []interface{}{1, 2, 2}, Of(1, 2, 2).FilterRuns(1).ToSlice())
+	assert.Equal(t, []interface{}{[]int{1}, []int{1}}, Of([]int{1}, []int{1}, []int{2}).FilterRuns(2).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1, 2, 3).FilterRuns(2).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().FilterRuns(2).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrMinRunGreaterThanZero, recover())
+		}()
+
+		Of().FilterRuns(0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestCount(t *testing.T) {
+	assert.Equal(t, 0, Of().Count())
+	assert.Equal(t, 1, Of(1).Count())
+	assert.Equal(t, 3, Of(1, 2, 3).Count())
+	assert.Equal(t, 3, Of(1, "2", []int{3}).Count())
+	assert.Equal(t, 2, Of(1, 2, 3, 4, 5).Filter(func(value interface{}) bool { return value.(int)%2 == 0 }).Count())
+
+	iter := Of(1)
+	iter.Count()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		iter.Next()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestDot(t *testing.T) {
+	assert.Equal(t, float64(32), Of(1, 2, 3).Dot(Of(4, 5, 6)))
+	assert.Equal(t, float64(14), Of(1, 2, 3).Dot(Of(4.0, 5.0)))
+	assert.Equal(t, float64(4), Of(uint8(1)).Dot(Of(int64(4), 5, 6)))
+	assert.Equal(t, float64(0), Of().Dot(Of(1)))
+}
+
+func TestFlatMap(t *testing.T) {
+	iter := Of(1, 2, 3).FlatMap(func(value interface{}) *Iter { return Of(value, value) })
+	assert.Equal(t, []interface{}{1, 1, 2, 2, 3, 3}, iter.ToSlice())
+
+	// Sub iters of differing lengths, including empty and nil
+	iter = Of(0, 1, 2, 3).FlatMap(func(value interface{}) *Iter {
+		switch n := value.(int); n {
+		case 0:
+			return Of()
+		case 2:
+			return nil
+		default:
+			return OfElements(make([]int, n))
+		}
+	})
+	assert.Equal(t, []interface{}{0, 0, 0, 0}, iter.ToSlice())
+
+	// Empty source
+	assert.False(t, Of().FlatMap(func(value interface{}) *Iter { return Of(value) }).Next())
+}
+
+func TestMinMaxScale(t *testing.T) {
+	assert.Equal(t, []float64{0, 0.5, 1}, Of(10, 20, 30).MinMaxScale())
+	assert.Equal(t, []float64{1, 0, 0.25}, Of(5.0, 1, 2).MinMaxScale())
+	assert.Equal(t, []float64{0, 0}, Of(3, 3).MinMaxScale())
+	assert.Equal(t, []float64{}, Of().MinMaxScale())
+}
+
+func TestDistinct(t *testing.T) {
+	assert.Equal(t, []interface{}{1, 2, 3}, Of(1, 2, 2, 3, 1, 3).Distinct().ToSlice())
+	assert.Equal(t, []interface{}{1, "1", nil}, Of(1, "1", nil, 1, nil).Distinct().ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Distinct().ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrDistinctComparable, recover())
+		}()
+
+		Of([]int{1}).Distinct().Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// A comparable type holding an incomparable value
+	assert.Equal(t, []interface{}{KeyValue{1, 1}}, Of(KeyValue{1, 1}, KeyValue{1, 1}).Distinct().ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrDistinctComparable, recover())
+		}()
+
+		Of(KeyValue{[]int{1}, 1}).Distinct().ToSlice()
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrDistinctComparable, recover())
+		}()
+
+		Of([1]interface{}{KeyValue{1, map[int]int{}}}).Distinct().ToSlice()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestTrace(t *testing.T) {
+	var (
+		log    = []string{}
+		logger = func(name string, index int, value interface{}) {
+			log = append(log, fmt.Sprintf("%s[%d]=%v", name, index, value))
+		}
+	)
+
+	iter := Of(1, 2, 3, 4).
+		Trace("source", logger).
+		Filter(func(value interface{}) bool { return value.(int)%2 == 0 }).
+		Trace("evens", logger)
+	assert.Equal(t, []interface{}{2, 4}, iter.ToSlice())
+	assert.Equal(
+		t,
+		[]string{"source[0]=1", "source[1]=2", "evens[0]=2", "source[2]=3", "source[3]=4", "evens[1]=4"},
+		log,
+	)
+}
+
+func TestPeek(t *testing.T) {
+	peeked := []interface{}{}
+	collected := Of(1, 2, 3).Peek(func(value interface{}) { peeked = append(peeked, value) }).ToSlice()
+	assert.Equal(t, []interface{}{1, 2, 3}, collected)
+	assert.Equal(t, collected, peeked)
+
+	// Only called for values actually pulled
+	peeked = []interface{}{}
+	iter := Of(1, 2, 3).Peek(func(value interface{}) { peeked = append(peeked, value) })
+	assert.Equal(t, 1, iter.NextValue())
+	assert.Equal(t, []interface{}{1}, peeked)
+}
+
+func TestCountByOf(t *testing.T) {
+	firstLetter := func(value interface{}) interface{} { return []rune(value.(string))[0] }
+
+	assert.Equal(
+		t,
+		map[rune]int{'a': 2, 'b': 1},
+		Of("apple", "banana", "avocado").CountByOf(firstLetter, rune(0)),
+	)
+	assert.Equal(t, map[rune]int{}, Of().CountByOf(firstLetter, rune(0)))
+
+	// Keys are converted to the type of keyProto
+	assert.Equal(
+		t,
+		map[int64]int{0: 2, 1: 1},
+		Of(1, 2, 4).CountByOf(func(value interface{}) interface{} { return value.(int) % 2 }, int64(0)),
+	)
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrValueCannotBeNil, recover())
+		}()
+
+		Of().CountByOf(firstLetter, nil)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestLimit(t *testing.T) {
+	assert.Equal(t, []interface{}{1, 2}, Of(1, 2, 3, 4).Limit(2).ToSlice())
+	assert.Equal(t, []interface{}{1, 2}, Of(1, 2).Limit(5).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1, 2).Limit(0).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Limit(2).ToSlice())
+
+	// Infinite source is not over consumed
+	var (
+		pulled   = 0
+		infinite = NewIter(func() (interface{}, bool) {
+			pulled++
+			return pulled, true
+		})
+	)
+
+	assert.Equal(t, []interface{}{1, 2, 3}, infinite.Limit(3).ToSlice())
+	assert.Equal(t, 3, pulled)
+}
+
+func TestAccumulate(t *testing.T) {
+	assert.Equal(t, []interface{}{"a", "a-b", "a-b-c"}, Of("a", "b", "c").Accumulate("-").ToSlice())
+	assert.Equal(t, []interface{}{"a", "ab"}, Of("a", 'b').Accumulate("").ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Accumulate("-").ToSlice())
+}
+
+func TestSkip(t *testing.T) {
+	assert.Equal(t, []interface{}{3, 4, 5}, Of(1, 2, 3, 4, 5).Skip(2).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1, 2).Skip(10).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1, 2).Skip(2).ToSlice())
+	assert.Equal(t, []interface{}{1, 2}, Of(1, 2).Skip(0).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Skip(1).ToSlice())
+}
+
+func TestFork(t *testing.T) {
+	var (
+		sink     []interface{}
+		sinkDone = make(chan struct{})
+	)
+
+	iter := Of(1, 2, 3).Fork(func(branch *Iter) {
+		defer close(sinkDone)
+		sink = branch.ToSlice()
+	})
+	assert.Equal(t, []interface{}{1, 2, 3}, iter.ToSlice())
+
+	<-sinkDone
+	assert.Equal(t, []interface{}{1, 2, 3}, sink)
+
+	// Sink that stops early does not block the main iter
+	sinkDone = make(chan struct{})
+	iter = Of(1, 2, 3).Fork(func(branch *Iter) {
+		defer close(sinkDone)
+		sink = []interface{}{branch.NextValue()}
+	})
+	assert.Equal(t, []interface{}{1, 2, 3}, iter.ToSlice())
+
+	<-sinkDone
+	assert.Equal(t, []interface{}{1}, sink)
+
+	// Closing an abandoned main iter ends the branch, so the consume goroutine exits
+	sinkDone = make(chan struct{})
+	iter = Of(1, 2, 3).Fork(func(branch *Iter) {
+		defer close(sinkDone)
+		sink = branch.ToSlice()
+	})
+	assert.Equal(t, 1, iter.NextValue())
+	iter.Close()
+	assert.False(t, iter.Next())
+
+	select {
+	case <-sinkDone:
+	case <-time.After(time.Second):
+		assert.Fail(t, "consume goroutine did not exit")
+	}
+	assert.Equal(t, []interface{}{1}, sink)
+
+	// Closing an abandoned Iter chained after Fork closes the Fork too
+	var (
+		source = Of(1, 2, 3, 4)
+		even   = func(value interface{}) bool { return value.(int)%2 == 0 }
+		double = func(value interface{}) interface{} { return value.(int) * 2 }
+	)
+
+	sinkDone = make(chan struct{})
+	iter = source.Fork(func(branch *Iter) {
+		defer close(sinkDone)
+		sink = branch.ToSlice()
+	}).Filter(even).Map(double)
+	assert.Equal(t, 4, iter.NextValue())
+	iter.Close()
+	assert.False(t, iter.Next())
+
+	select {
+	case <-sinkDone:
+	case <-time.After(time.Second):
+		assert.Fail(t, "consume goroutine did not exit")
+	}
+	assert.Equal(t, []interface{}{1, 2}, sink)
+
+	// The source is closed too
+	assert.False(t, source.Next())
+}
+
+func TestClose(t *testing.T) {
+	iter := Of(1, 2, 3)
+	assert.Equal(t, 1, iter.NextValue())
+	iter.Unread(1)
+	iter.Close()
+	assert.False(t, iter.Next())
+
+	// Closing again, or closing an exhausted iter, has no effect
+	iter.Close()
+	iter = Of()
+	assert.False(t, iter.Next())
+	iter.Close()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		iter.Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Closing a derived Iter closes the Iters it reads from
+	var (
+		a = Of(1, 2)
+		b = Of(3, 4)
+	)
+
+	iter = Concat(a.Map(func(value interface{}) interface{} { return value }), b).Filter(func(interface{}) bool { return true })
+	assert.Equal(t, 1, iter.NextValue())
+	iter.Close()
+	assert.False(t, iter.Next())
+	assert.False(t, a.Next())
+	assert.False(t, b.Next())
+
+	// A nil source is skipped
+	iter = Of(1).OrElse(nil)
+	iter.Close()
+	assert.False(t, iter.Next())
+}
+
+func TestTakeWhile(t *testing.T) {
+	lessThan3 := func(value interface{}) bool { return value.(int) < 3 }
+
+	source := Of(1, 2, 3, 4)
+	assert.Equal(t, []interface{}{1, 2}, source.TakeWhile(lessThan3).ToSlice())
+
+	// First failing value is consumed
+	assert.Equal(t, 4, source.NextValue())
+
+	assert.Equal(t, []interface{}{1, 2}, Of(1, 2).TakeWhile(func(interface{}) bool { return true }).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(3, 1).TakeWhile(lessThan3).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().TakeWhile(lessThan3).ToSlice())
+}
+
+func TestTranslate(t *testing.T) {
+	labels := map[interface{}]interface{}{200: "OK", 404: "Not Found"}
+
+	assert.Equal(t, []interface{}{"OK", 500, "Not Found"}, Of(200, 500, 404).Translate(labels, true).ToSlice())
+	assert.Equal(t, []interface{}{"OK", "Not Found"}, Of(200, 500, 404).Translate(labels, false).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Translate(labels, true).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrTranslateComparable, recover())
+		}()
+
+		Of([]int{200}).Translate(labels, true).Next()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestDropWhile(t *testing.T) {
+	lessThan3 := func(value interface{}) bool { return value.(int) < 3 }
+
+	assert.Equal(t, []interface{}{3, 1, 2}, Of(1, 2, 3, 1, 2).DropWhile(lessThan3).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1, 2).DropWhile(func(interface{}) bool { return true }).ToSlice())
+	assert.Equal(t, []interface{}{1, 2}, Of(1, 2).DropWhile(func(interface{}) bool { return false }).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().DropWhile(lessThan3).ToSlice())
+}
+
+func TestMaxLen(t *testing.T) {
+	// Exactly n
+	iter := Of(1, 2, 3).MaxLen(3)
+	assert.Equal(t, []interface{}{1, 2, 3}, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+
+	// Fewer than n
+	iter = Of(1, 2).MaxLen(3)
+	assert.Equal(t, []interface{}{1, 2}, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+
+	// More than n
+	iter = Of(1, 2, 3, 4).MaxLen(3)
+	assert.Equal(t, []interface{}{1, 2, 3}, iter.ToSlice())
+	assert.Equal(t, ErrMaxLenExceeded, iter.Err().Error())
+
+	iter = Of(1).MaxLen(0)
+	assert.False(t, iter.Next())
+	assert.Equal(t, ErrMaxLenExceeded, iter.Err().Error())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNGreaterThanOrEqualZero, recover())
+		}()
+
+		Of().MaxLen(-1)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestStats(t *testing.T) {
+	count, mean, variance, stddev := Of(2, 4, 4, 4, 5, 5, 7, 9).Stats()
+	assert.Equal(t, 8, count)
+	assert.Equal(t, 5.0, mean)
+	assert.Equal(t, 4.0, variance)
+	assert.Equal(t, 2.0, stddev)
+
+	// Large near-equal values
+	count, mean, variance, _ = Of(1e9+4, 1e9+7, 1e9+13, 1e9+16).Stats()
+	assert.Equal(t, 4, count)
+	assert.Equal(t, 1e9+10, mean)
+	assert.InDelta(t, 22.5, variance, 1e-6)
+
+	count, mean, variance, stddev = Of().Stats()
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 0.0, mean)
+	assert.Equal(t, 0.0, variance)
+	assert.Equal(t, 0.0, stddev)
+}
+
+func TestChunkWhileOrdered(t *testing.T) {
+	descending := func(prev, curr interface{}) bool { return curr.(int) < prev.(int) }
+
+	assert.Equal(
+		t,
+		[]interface{}{[]interface{}{1, 2, 3}, []interface{}{1, 2}},
+		Of(1, 2, 3, 1, 2).ChunkWhileOrdered(descending).ToSlice(),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{[]interface{}{3}, []interface{}{2}, []interface{}{1}},
+		Of(3, 2, 1).ChunkWhileOrdered(descending).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{}, Of().ChunkWhileOrdered(descending).ToSlice())
+}
+
+func TestToMap(t *testing.T) {
+	assert.Equal(t, map[int]int{1: 2, 3: 4}, OfElements(map[int]int{1: 2, 3: 4}).ToMap(0, 0))
+	assert.Equal(t, map[int64]string{1: "a"}, Of(KeyValue{1, "a"}).ToMap(int64(0), ""))
+	assert.Equal(t, map[string]int{}, Of().ToMap("", 0))
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrToMapKeyValue, recover())
+		}()
+
+		Of(1).ToMap(0, 0)
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrValueCannotBeNil, recover())
+		}()
+
+		Of().ToMap(0, nil)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestDuplicates(t *testing.T) {
+	assert.Equal(t, []interface{}{2, 3}, Of(1, 2, 2, 3, 3, 3).Duplicates().ToSlice())
+	assert.Equal(t, []interface{}{1, 2}, Of(1, 2, 1, 2, 1).Duplicates().ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1, 2, 3).Duplicates().ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrDuplicatesComparable, recover())
+		}()
+
+		Of([]int{1}).Duplicates().Next()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestGroupBy(t *testing.T) {
+	mod2 := func(value interface{}) interface{} { return value.(int) % 2 }
+
+	assert.Equal(
+		t,
+		map[interface{}][]interface{}{0: {2, 4, 6}, 1: {1, 3, 5}},
+		Of(1, 2, 3, 4, 5, 6).GroupBy(mod2),
+	)
+	assert.Equal(t, map[interface{}][]interface{}{}, Of().GroupBy(mod2))
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrGroupByComparable, recover())
+		}()
+
+		Of(1).GroupBy(func(value interface{}) interface{} { return []interface{}{value} })
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestStratifiedSample(t *testing.T) {
+	var (
+		mod2   = func(value interface{}) interface{} { return value.(int) % 2 }
+		values = make([]int, 100)
+		sample = func(seed int64) map[interface{}][]interface{} {
+			return OfElements(values).StratifiedSample(mod2, 3, rand.New(rand.NewSource(seed)))
+		}
+	)
+
+	for i := range values {
+		values[i] = i
+	}
+
+	samples := sample(42)
+
+	assert.Equal(t, 2, len(samples))
+	for key, sampled := range samples {
+		assert.Equal(t, 3, len(sampled))
+		for _, value := range sampled {
+			assert.Equal(t, key, mod2(value))
+		}
+	}
+
+	// Same seed, same samples
+	assert.Equal(t, samples, sample(42))
+
+	// Keys with no more than perKey values keep all values
+	assert.Equal(
+		t,
+		map[interface{}][]interface{}{0: {0, 2}, 1: {1}},
+		Of(0, 1, 2).StratifiedSample(mod2, 3, rand.New(rand.NewSource(1))),
+	)
+	assert.Equal(t, map[interface{}][]interface{}{}, Of().StratifiedSample(mod2, 3, rand.New(rand.NewSource(1))))
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrPerKeyGreaterThanZero, recover())
+		}()
+
+		Of().StratifiedSample(mod2, 0, rand.New(rand.NewSource(1)))
+		assert.Fail(t, "Must panic")
+	}()
+
+	// A nil rng panics up front, even when no value would need it
+	func() {
+		defer func() {
+			assert.Equal(t, ErrRandCannotBeNil, recover())
+		}()
+
+		Of(0, 1).StratifiedSample(mod2, 3, nil)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestSorted(t *testing.T) {
+	intLess := func(a, b interface{}) bool { return a.(int) < b.(int) }
+
+	assert.Equal(t, []interface{}{1, 2, 3}, Of(3, 1, 2).Sorted(intLess).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Sorted(intLess).ToSlice())
+
+	// Stable sort of pairs by key only
+	keyLess := func(a, b interface{}) bool { return a.(KeyValue).Key.(int) < b.(KeyValue).Key.(int) }
+	assert.Equal(
+		t,
+		[]interface{}{KeyValue{1, "b"}, KeyValue{1, "a"}, KeyValue{2, "c"}, KeyValue{2, "a"}},
+		Of(KeyValue{2, "c"}, KeyValue{1, "b"}, KeyValue{2, "a"}, KeyValue{1, "a"}).Sorted(keyLess).ToSlice(),
+	)
+}
+
+func TestMinMax(t *testing.T) {
+	intLess := func(a, b interface{}) bool { return a.(int) < b.(int) }
+
+	value, haveIt := Of(3, 1, 2).Min(intLess)
+	assert.Equal(t, 1, value)
+	assert.True(t, haveIt)
+
+	value, haveIt = Of(3, 1, 2).Max(intLess)
+	assert.Equal(t, 3, value)
+	assert.True(t, haveIt)
+
+	value, haveIt = Of().Min(intLess)
+	assert.Nil(t, value)
+	assert.False(t, haveIt)
+
+	value, haveIt = Of().Max(intLess)
+	assert.Nil(t, value)
+	assert.False(t, haveIt)
+
+	// First of equal extremes
+	keyLess := func(a, b interface{}) bool { return a.(KeyValue).Key.(int) < b.(KeyValue).Key.(int) }
+
+	value, _ = Of(KeyValue{1, "a"}, KeyValue{2, "b"}, KeyValue{1, "c"}).Min(keyLess)
+	assert.Equal(t, KeyValue{1, "a"}, value)
+
+	value, _ = Of(KeyValue{2, "a"}, KeyValue{1, "b"}, KeyValue{2, "c"}).Max(keyLess)
+	assert.Equal(t, KeyValue{2, "a"}, value)
+}
+
+func TestGrep(t *testing.T) {
+	var (
+		lines = "INFO start\nERROR failed\nINFO retry\nERROR failed again"
+		re    = regexp.MustCompile("^ERROR")
+	)
+
+	assert.Equal(t, []interface{}{"ERROR failed", "ERROR failed again"}, OfReaderLines(strings.NewReader(lines)).Grep(re).ToSlice())
+	assert.Equal(t, []interface{}{"INFO start", "INFO retry"}, OfReaderLines(strings.NewReader(lines)).GrepV(re).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Grep(re).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrRegexpCannotBeNil, recover())
+		}()
+
+		Of().Grep(nil)
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrRegexpCannotBeNil, recover())
+		}()
+
+		Of().GrepV(nil)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestSumAndAverage(t *testing.T) {
+	assert.Equal(t, int64(6), Of(1, 2, 3).SumInt())
+	assert.Equal(t, int64(6), Of(1, int8(2), uint16(3)).SumInt())
+	assert.Equal(t, int64(0), Of().SumInt())
+
+	assert.Equal(t, 6.5, Of(1, 2.5, float32(3)).SumFloat())
+	assert.Equal(t, 0.0, Of().SumFloat())
+
+	avg, haveIt := Of(2.0, 4.0).AverageFloat()
+	assert.Equal(t, 3.0, avg)
+	assert.True(t, haveIt)
+
+	avg, haveIt = Of().AverageFloat()
+	assert.Equal(t, 0.0, avg)
+	assert.False(t, haveIt)
+}
+
+func TestPipe(t *testing.T) {
+	doubler := func(in *Iter) *Iter {
+		return in.Map(func(value interface{}) interface{} { return value.(int) * 2 })
+	}
+
+	assert.Equal(
+		t,
+		[]interface{}{4, 8},
+		Of(1, 2, 3, 4).Pipe(doubler).Filter(func(value interface{}) bool { return value.(int)%4 == 0 }).ToSlice(),
+	)
+}
+
+func TestFirst(t *testing.T) {
+	isEven := func(value interface{}) bool { return value.(int)%2 == 0 }
+
+	iter := Of(1, 3, 4, 6)
+	value, haveIt := iter.First(isEven)
+	assert.Equal(t, 4, value)
+	assert.True(t, haveIt)
+
+	// Stops at first match
+	assert.Equal(t, 6, iter.NextValue())
+
+	value, haveIt = Of(1, 3, 5).First(isEven)
+	assert.Nil(t, value)
+	assert.False(t, haveIt)
+
+	value, haveIt = Of().First(isEven)
+	assert.Nil(t, value)
+	assert.False(t, haveIt)
+}
+
+func TestToArrayOf(t *testing.T) {
+	assert.Equal(t, [3]int{1, 2, 3}, Of(1, 2, 3).ToArrayOf([3]int{}))
+	assert.Equal(t, [2]float64{1, 2.5}, Of(1, 2.5).ToArrayOf([2]float64{}))
+	assert.Equal(t, [0]int{}, Of().ToArrayOf([0]int{}))
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrToArrayOfTooFewValues, recover())
+		}()
+
+		Of(1, 2).ToArrayOf([3]int{})
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrToArrayOfTooManyValues, recover())
+		}()
+
+		Of(1, 2, 3, 4).ToArrayOf([3]int{})
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrToArrayOfArg, recover())
+		}()
+
+		Of(1).ToArrayOf([]int{})
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrToArrayOfArg, recover())
+		}()
+
+		Of(1).ToArrayOf(nil)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestAllAnyNoneMatch(t *testing.T) {
+	isEven := func(value interface{}) bool { return value.(int)%2 == 0 }
+
+	// AllMatch
+	assert.True(t, Of(2, 4).AllMatch(isEven))
+	assert.False(t, Of(2, 3, 4).AllMatch(isEven))
+	assert.True(t, Of().AllMatch(isEven))
+
+	// AnyMatch
+	assert.True(t, Of(1, 2, 3).AnyMatch(isEven))
+	assert.False(t, Of(1, 3).AnyMatch(isEven))
+	assert.False(t, Of().AnyMatch(isEven))
+
+	// NoneMatch
+	assert.True(t, Of(1, 3).NoneMatch(isEven))
+	assert.False(t, Of(1, 2, 3).NoneMatch(isEven))
+	assert.True(t, Of().NoneMatch(isEven))
+
+	// Short circuit on an infinite source
+	var (
+		n        = 0
+		infinite = func() *Iter {
+			n = 0
+			return NewIter(func() (interface{}, bool) {
+				n++
+				return n, true
+			})
+		}
+	)
+
+	assert.False(t, infinite().AllMatch(func(value interface{}) bool { return value.(int) < 3 }))
+	assert.Equal(t, 3, n)
+	assert.True(t, infinite().AnyMatch(isEven))
+	assert.Equal(t, 2, n)
+	assert.False(t, infinite().NoneMatch(isEven))
+	assert.Equal(t, 2, n)
+}
+
+func TestMapEAndSkipErrors(t *testing.T) {
+	parse := func(value interface{}) (interface{}, error) { return strconv.Atoi(value.(string)) }
+
+	// MapE stops on first error
+	iter := Of("1", "x", "2").MapE(parse)
+	assert.Equal(t, []interface{}{1}, iter.ToSlice())
+	assert.NotNil(t, iter.Err())
+	assert.Nil(t, iter.Errors())
+
+	iter = Of("1", "2").MapE(parse)
+	assert.Equal(t, []interface{}{1, 2}, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+
+	// SkipErrors continues past errors
+	iter = Of("1", "x", "2", "y", "3").MapE(parse).SkipErrors()
+	assert.Equal(t, []interface{}{1, 2, 3}, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+	assert.Equal(t, 2, len(iter.Errors()))
+	assert.Contains(t, iter.Errors()[0].Error(), `"x"`)
+	assert.Contains(t, iter.Errors()[1].Error(), `"y"`)
+
+	// Unread values of the source are returned first
+	source := Of("1", "2").MapE(parse)
+	source.Unread(0)
+	assert.Equal(t, []interface{}{0, 1, 2}, source.SkipErrors().ToSlice())
+
+	// Iter that cannot fail
+	iter = Of(1, 2).SkipErrors()
+	assert.Equal(t, []interface{}{1, 2}, iter.ToSlice())
+	assert.Nil(t, iter.Errors())
+
+	// Iter that cannot continue after failure
+	iter = Of(1, 2).MaxLen(1).SkipErrors()
+	assert.Equal(t, []interface{}{1}, iter.ToSlice())
+	assert.Equal(t, 1, len(iter.Errors()))
+	assert.Equal(t, ErrMaxLenExceeded, iter.Errors()[0].Error())
+
+	// Source that keeps failing once it fails
+	errFail := fmt.Errorf("failed")
+	iter = NewIterErr(func() (interface{}, bool, error) { return nil, false, errFail }).SkipErrors()
+	assert.Equal(t, []interface{}{}, iter.ToSlice())
+	assert.Equal(t, []error{errFail}, iter.Errors())
+
+	iter = OfJSONArray(strings.NewReader("[1, 2, }")).SkipErrors()
+	assert.Equal(t, []interface{}{1.0, 2.0}, iter.ToSlice())
+	assert.Equal(t, 1, len(iter.Errors()))
+}
+
+func TestSelfProduct(t *testing.T) {
+	assert.Equal(
+		t,
+		[]interface{}{[2]interface{}{1, 2}, [2]interface{}{1, 3}, [2]interface{}{2, 3}},
+		Of(1, 2, 3).SelfProduct(false).ToSlice(),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{
+			[2]interface{}{1, 1}, [2]interface{}{1, 2}, [2]interface{}{1, 3},
+			[2]interface{}{2, 2}, [2]interface{}{2, 3},
+			[2]interface{}{3, 3},
+		},
+		Of(1, 2, 3).SelfProduct(true).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{}, Of(1).SelfProduct(false).ToSlice())
+	assert.Equal(t, []interface{}{[2]interface{}{1, 1}}, Of(1).SelfProduct(true).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().SelfProduct(false).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().SelfProduct(true).ToSlice())
+}
+
+func TestSelfProductOrdered(t *testing.T) {
+	assert.Equal(
+		t,
+		[]interface{}{
+			[2]interface{}{1, 2}, [2]interface{}{1, 3},
+			[2]interface{}{2, 1}, [2]interface{}{2, 3},
+			[2]interface{}{3, 1}, [2]interface{}{3, 2},
+		},
+		Of(1, 2, 3).SelfProductOrdered(false).ToSlice(),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{
+			[2]interface{}{1, 1}, [2]interface{}{1, 2},
+			[2]interface{}{2, 1}, [2]interface{}{2, 2},
+		},
+		Of(1, 2).SelfProductOrdered(true).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{}, Of(1).SelfProductOrdered(false).ToSlice())
+	assert.Equal(t, []interface{}{[2]interface{}{1, 1}}, Of(1).SelfProductOrdered(true).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().SelfProductOrdered(true).ToSlice())
+}
+
+func TestToChannel(t *testing.T) {
+	ch := make(chan interface{}, 3)
+	Of(1, 2, 3).ToChannel(ch)
+
+	values := []interface{}{}
+	for value := range ch {
+		values = append(values, value)
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, values)
+
+	// Async
+	values = []interface{}{}
+	for value := range Of(1, 2, 3).ToChannelAsync(0) {
+		values = append(values, value)
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, values)
+
+	// Round trip with OfChannel
+	assert.Equal(t, []interface{}{1, 2}, OfChannel(Of(1, 2).ToChannelAsync(1)).ToSlice())
+
+	// Empty
+	_, open := <-Of().ToChannelAsync(0)
+	assert.False(t, open)
+}
+
+func TestMapChunks(t *testing.T) {
+	reverse := func(chunk []interface{}) []interface{} {
+		result := make([]interface{}, len(chunk))
+		for i, value := range chunk {
+			result[len(chunk)-1-i] = value
+		}
+
+		return result
+	}
+
+	assert.Equal(t, []interface{}{3, 2, 1, 6, 5, 4, 8, 7}, Of(1, 2, 3, 4, 5, 6, 7, 8).MapChunks(3, reverse).ToSlice())
+	assert.Equal(t, []interface{}{3, 2, 1}, Of(1, 2, 3).MapChunks(3, reverse).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().MapChunks(3, reverse).ToSlice())
+
+	// Chunks that map to nothing are skipped
+	chunks := 0
+	dropOdd := func(chunk []interface{}) []interface{} {
+		chunks++
+		if chunks%2 == 1 {
+			return nil
+		}
+
+		return chunk
+	}
+	assert.Equal(t, []interface{}{3, 4}, Of(1, 2, 3, 4, 5).MapChunks(2, dropOdd).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrSizeGreaterThanZero, recover())
+		}()
+
+		Of().MapChunks(0, reverse)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestDecaySum(t *testing.T) {
+	// With a half life of 1, each previous value is worth half as much
+	assert.Equal(t, []interface{}{4.0, 2.0 + 4, 1.0 + 2 + 4}, Of(4, 4, 4).DecaySum(1).ToSlice())
+
+	// Value half life steps ago has half the weight
+	sums := Of(8, 0, 0).DecaySum(2).ToSlice()
+	assert.InDelta(t, 4.0, sums[2], 1e-9)
+
+	// Constant input approaches steady state of value / (1 - decay)
+	var (
+		decay       = math.Pow(0.5, 1.0/3)
+		steadyState = 1 / (1 - decay)
+		last        interface{}
+	)
+
+	Of(make([]interface{}, 200)...).Map(func(interface{}) interface{} { return 1 }).DecaySum(3).ForEach(func(value interface{}) {
+		assert.True(t, (last == nil) || (value.(float64) >= last.(float64)))
+		last = value
+	})
+	assert.InDelta(t, steadyState, last, 1e-9)
+
+	assert.Equal(t, []interface{}{}, Of().DecaySum(1).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrHalfLifeGreaterThanZero, recover())
+		}()
+
+		Of().DecaySum(0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestSplitOnIndent(t *testing.T) {
+	lines := "root:\n  child:\n    leaf\n\tx\n \t y\n"
+
+	assert.Equal(
+		t,
+		[]interface{}{
+			KeyValue{0, "root:"},
+			KeyValue{2, "  child:"},
+			KeyValue{4, "    leaf"},
+			KeyValue{1, "\tx"},
+			KeyValue{3, " \t y"},
+		},
+		OfReaderLines(strings.NewReader(lines)).SplitOnIndent().ToSlice(),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{KeyValue{4, "\tx"}, KeyValue{6, " \t y"}, KeyValue{0, ""}},
+		Of("\tx", " \t y", "").SplitOnIndentTabWidth(4).ToSlice(),
+	)
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrTabWidthGreaterThanZero, recover())
+		}()
+
+		Of().SplitOnIndentTabWidth(0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestSpillToFile(t *testing.T) {
+	var (
+		path    = filepath.Join(t.TempDir(), "spill")
+		marshal = func(value interface{}) ([]byte, error) { return []byte(value.(string)), nil }
+		toStr   = func(value interface{}) interface{} { return string(value.([]byte)) }
+	)
+
+	iter, err := Of("a", "", "long value").SpillToFile(path, marshal)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "", "long value"}, iter.Map(toStr).ToSlice())
+	assert.Nil(t, iter.Err())
+
+	// Re-read
+	iter, err = OfSpillFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "", "long value"}, iter.Map(toStr).ToSlice())
+
+	// Empty
+	iter, err = Of().SpillToFile(path, marshal)
+	assert.Nil(t, err)
+	assert.False(t, iter.Next())
+
+	// Marshal error
+	errMarshal := fmt.Errorf("marshal")
+	iter, err = Of("a").SpillToFile(path, func(interface{}) ([]byte, error) { return nil, errMarshal })
+	assert.Nil(t, iter)
+	assert.Equal(t, errMarshal, err)
+
+	// Write error
+	iter, err = Of("a").SpillToFile(filepath.Join(path, "missing", "spill"), marshal)
+	assert.Nil(t, iter)
+	assert.NotNil(t, err)
+
+	// Open error
+	iter, err = OfSpillFile(filepath.Join(path, "missing"))
+	assert.Nil(t, iter)
+	assert.NotNil(t, err)
+
+	// Truncated file
+	_, err = Of("abc").SpillToFile(path, marshal)
+	assert.Nil(t, err)
+	assert.Nil(t, os.Truncate(path, 9))
+
+	iter, err = OfSpillFile(path)
+	assert.Nil(t, err)
+	assert.False(t, iter.Next())
+	assert.Equal(t, ErrSpillFileRecordLength, iter.Err().Error())
+
+	// Truncated length
+	assert.Nil(t, os.Truncate(path, 5))
+
+	iter, err = OfSpillFile(path)
+	assert.Nil(t, err)
+	assert.False(t, iter.Next())
+	assert.Equal(t, io.ErrUnexpectedEOF, iter.Err())
+
+	// Corrupt length that is far larger than the file
+	assert.Nil(t, os.WriteFile(path, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 'a'}, 0600))
+
+	iter, err = OfSpillFile(path)
+	assert.Nil(t, err)
+	assert.False(t, iter.Next())
+	assert.Equal(t, ErrSpillFileRecordLength, iter.Err().Error())
+
+	// Close an abandoned iter
+	_, err = Of("a", "b").SpillToFile(path, marshal)
+	assert.Nil(t, err)
+
+	iter, err = OfSpillFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("a"), iter.NextValue())
+	iter.Close()
+	assert.False(t, iter.Next())
+}
+
+func TestRateLimitConcurrency(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+		processed   = map[interface{}]bool{}
+		wg          sync.WaitGroup
+	)
+
+	iter := OfRange(0, 20, 1).RateLimitConcurrency(3)
+	for iter.Next() {
+		value := iter.Value().(InFlight)
+
+		mu.Lock()
+		if inFlight++; inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			processed[value.Value] = true
+			mu.Unlock()
+
+			value.Release()
+			value.Release()
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 20, len(processed))
+	assert.True(t, maxInFlight <= 3)
+
+	// Release can be called in the same goroutine
+	iter = Of(1, 2).RateLimitConcurrency(1)
+	for iter.Next() {
+		iter.Value().(InFlight).Release()
+	}
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrMaxInFlightGreaterThanZero, recover())
+		}()
+
+		Of().RateLimitConcurrency(0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestUnzip(t *testing.T) {
+	keys, values := OfMapInOrder(map[string]int{"a": 1, "b": 2}, []string{"a", "b"}).Unzip()
+	assert.Equal(t, []interface{}{"a", "b"}, keys)
+	assert.Equal(t, []interface{}{1, 2}, values)
+
+	// Map in random order
+	keys, values = OfElements(map[string]int{"a": 1, "b": 2}).Unzip()
+	assert.ElementsMatch(t, []interface{}{"a", "b"}, keys)
+	assert.ElementsMatch(t, []interface{}{1, 2}, values)
+
+	// Inverse of Zip
+	keys, values = Zip(Of(1, 2), Of("x", "y")).Unzip()
+	assert.Equal(t, []interface{}{1, 2}, keys)
+	assert.Equal(t, []interface{}{"x", "y"}, values)
+
+	keys, values = Of().Unzip()
+	assert.Equal(t, []interface{}{}, keys)
+	assert.Equal(t, []interface{}{}, values)
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrUnzipKeyValue, recover())
+		}()
+
+		Of(1).Unzip()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestWithContext(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		n           = 0
+		iter        = Generate(func() interface{} {
+			n++
+			return n
+		}).Limit(10).WithContext(ctx)
+		values = []interface{}{}
+	)
+	defer cancel()
+
+	for iter.Next() {
+		value := iter.Value()
+		values = append(values, value)
+		if value == 3 {
+			cancel()
+		}
+	}
+
+	assert.Equal(t, []interface{}{1, 2, 3}, values)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, context.Canceled, iter.Err())
+
+	// Not cancelled
+	iter = Of(1, 2).WithContext(context.Background())
+	assert.Equal(t, []interface{}{1, 2}, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+}
+
+func TestOrElse(t *testing.T) {
+	// Non-empty source does not read fallback
+	fallback := Of(9)
+	assert.Equal(t, []interface{}{1, 2}, Of(1, 2).OrElse(fallback).ToSlice())
+	assert.Equal(t, 9, fallback.NextValue())
+
+	// Empty source
+	assert.Equal(t, []interface{}{9}, Of().OrElse(Of(9)).ToSlice())
+
+	// Source that fails
+	parse := func(value interface{}) (interface{}, error) { return strconv.Atoi(value.(string)) }
+	assert.Equal(t, []interface{}{9}, Of("x").MapE(parse).OrElse(Of(9)).ToSlice())
+	assert.Equal(t, []interface{}{1, 9}, Of("1", "x").MapE(parse).OrElse(Of(9)).ToSlice())
+
+	// Nil fallback
+	assert.Equal(t, []interface{}{}, Of().OrElse(nil).ToSlice())
+}
+
+func TestCommonPrefix(t *testing.T) {
+	assert.Equal(t, "ab", Of("abcd", "abce", "abx").CommonPrefix())
+	assert.Equal(t, "abcd", Of("abcd").CommonPrefix())
+	assert.Equal(t, "", Of().CommonPrefix())
+
+	// Does not split a multibyte rune
+	assert.Equal(t, "a", Of("aé", "aè").CommonPrefix())
+
+	// Stops reading once the prefix is empty
+	iter := Of("abc", "xyz", 1)
+	assert.Equal(t, "", iter.CommonPrefix())
+	assert.Equal(t, 1, iter.NextValue())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrCommonPrefixString, recover())
+		}()
+
+		Of("abc", 1).CommonPrefix()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestPeaksValleys(t *testing.T) {
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+
+	assert.Equal(t, []interface{}{3, 5}, Of(1, 3, 2, 5, 4).Peaks(less).ToSlice())
+	assert.Equal(t, []interface{}{2}, Of(1, 3, 2, 5, 4).Valleys(less).ToSlice())
+
+	// Boundaries are not considered
+	assert.Equal(t, []interface{}{}, Of(5, 1, 5).Peaks(less).ToSlice())
+	assert.Equal(t, []interface{}{1}, Of(5, 1, 5).Valleys(less).ToSlice())
+
+	// Plateaus are not strict extrema
+	assert.Equal(t, []interface{}{}, Of(1, 3, 3, 1).Peaks(less).ToSlice())
+
+	assert.Equal(t, []interface{}{}, Of().Peaks(less).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1).Peaks(less).ToSlice())
+	assert.Equal(t, []interface{}{}, Of(1, 2).Valleys(less).ToSlice())
+}
+
+func TestToGobOfGob(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	gob.Register(point{})
+	gob.Register(KeyValue{})
+
+	var (
+		buf    bytes.Buffer
+		values = []interface{}{1, "two", 3.5, []int{4, 5}, point{6, 7}, KeyValue{Key: "eight", Value: 8}}
+	)
+
+	assert.Nil(t, OfElements(values).ToGob(&buf))
+
+	iter := OfGob(&buf)
+	assert.Equal(t, values, iter.ToSlice())
+	assert.Nil(t, iter.Err())
+
+	// Empty
+	buf.Reset()
+	assert.Nil(t, Of().ToGob(&buf))
+	assert.Equal(t, []interface{}{}, OfGob(&buf).ToSlice())
+
+	// Unregistered type cannot be encoded
+	type unregistered struct {
+		A int
+	}
+	assert.NotNil(t, Of(unregistered{1}).ToGob(&buf))
+
+	// Corrupt data surfaces via Err
+	buf.Reset()
+	assert.Nil(t, Of(1, 2).ToGob(&buf))
+	iter = OfGob(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	assert.Equal(t, []interface{}{1}, iter.ToSlice())
+	assert.NotNil(t, iter.Err())
+}
+
+func TestEnumerate(t *testing.T) {
+	assert.Equal(
+		t,
+		[]interface{}{KeyValue{0, "a"}, KeyValue{1, "b"}, KeyValue{2, "c"}},
+		Of("a", "b", "c").Enumerate().ToSlice(),
+	)
+	assert.Equal(t, []interface{}{}, Of().Enumerate().ToSlice())
+}
+
+func TestWindow(t *testing.T) {
+	assert.Equal(
+		t,
+		[]interface{}{[]interface{}{1, 2}, []interface{}{2, 3}, []interface{}{3, 4}},
+		Of(1, 2, 3, 4).Window(2).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{[]interface{}{1, 2, 3}}, Of(1, 2, 3).Window(3).ToSlice())
+	assert.Equal(t, []interface{}{[]interface{}{1}, []interface{}{2}}, Of(1, 2).Window(1).ToSlice())
+
+	// Short source
+	assert.Equal(t, []interface{}{}, Of(1, 2).Window(3).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Window(1).ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrSizeGreaterThanZero, recover())
+		}()
+
+		Of(1).Window(0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestChunk(t *testing.T) {
+	assert.Equal(
+		t,
+		[]interface{}{[]interface{}{1, 2}, []interface{}{3, 4}, []interface{}{5}},
+		Of(1, 2, 3, 4, 5).Chunk(2).ToSlice(),
+	)
+	assert.Equal(t, []interface{}{[]interface{}{1, 2}, []interface{}{3, 4}}, Of(1, 2, 3, 4).Chunk(2).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Chunk(2).ToSlice())
+
+	// Only one chunk is read at a time
+	source := Of(1, 2, 3)
+	chunks := source.Chunk(2)
+	assert.Equal(t, []interface{}{1, 2}, chunks.NextValue())
+	assert.Equal(t, 3, source.NextValue())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrSizeGreaterThanZero, recover())
+		}()
+
+		Of(1).Chunk(0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestPartition(t *testing.T) {
+	even := func(value interface{}) bool { return value.(int)%2 == 0 }
+
+	matched, unmatched := Of(1, 2, 3, 4).Partition(even)
+	assert.Equal(t, []interface{}{2, 4}, matched)
+	assert.Equal(t, []interface{}{1, 3}, unmatched)
+
+	matched, unmatched = Of().Partition(even)
+	assert.Equal(t, []interface{}{}, matched)
+	assert.Equal(t, []interface{}{}, unmatched)
+}
+
+func TestFlatten(t *testing.T) {
+	assert.Equal(t, []interface{}{1, 2, 3, 4, 5}, Of(Of(1, 2), 3, Of(Of(4), 5)).Flatten().ToSlice())
+
+	// Iterables, empty and nil iters, and values that are not flattened
+	var nilIter *Iter
+	assert.Equal(
+		t,
+		[]interface{}{1, 2, 3, []int{4, 5}, 6},
+		Of(Iterable{IterableArraySliceFunc([]int{1, 2})}, Of(), nilIter, &Iterable{IterableArraySliceFunc([]int{3})}, []int{4, 5}, Of(6)).Flatten().ToSlice(),
+	)
+
+	assert.Equal(t, []interface{}{}, Of().Flatten().ToSlice())
+
+	// Deep nesting
+	deep := Of(1)
+	for i := 0; i < 100000; i++ {
+		deep = Of(deep)
+	}
+	assert.Equal(t, []interface{}{1}, deep.Flatten().ToSlice())
+}
+
+func TestToMapBy(t *testing.T) {
+	firstLetter := func(value interface{}) interface{} { return value.(string)[0:1] }
+
+	assert.Equal(
+		t,
+		map[interface{}]interface{}{"a": "avocado", "b": "banana"},
+		Of("apple", "avocado", "banana").ToMapBy(firstLetter),
+	)
+	assert.Equal(t, map[interface{}]interface{}{}, Of().ToMapBy(firstLetter))
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrToMapByComparable, recover())
+		}()
+
+		Of("apple").ToMapBy(func(value interface{}) interface{} { return []string{value.(string)} })
+		assert.Fail(t, "Must panic")
+	}()
+
+	// A comparable key type holding an incomparable value
+	func() {
+		defer func() {
+			assert.Equal(t, ErrToMapByComparable, recover())
+		}()
+
+		Of("apple").ToMapBy(func(value interface{}) interface{} { return KeyValue{[]int{1}, value} })
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestAppend(t *testing.T) {
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, Of(1, 2).Append(3, 4).ToSlice())
+	assert.Equal(t, []interface{}{9}, Of().Append(9).ToSlice())
+	assert.Equal(t, []interface{}{1}, Of(1).Append().ToSlice())
+
+	// Source is read lazily
+	source := Of(1, 2)
+	iter := source.Append(3)
+	assert.Equal(t, 1, iter.NextValue())
+	assert.Equal(t, 2, source.NextValue())
+	assert.Equal(t, []interface{}{3}, iter.ToSlice())
+}
+
+func TestDedup(t *testing.T) {
+	assert.Equal(t, []interface{}{1, 2, 1, 3}, Of(1, 1, 2, 2, 1, 3, 3).Dedup().ToSlice())
+	assert.Equal(t, []interface{}{nil, 1, nil}, Of(nil, nil, 1, nil).Dedup().ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Dedup().ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrDedupComparable, recover())
+		}()
+
+		Of([]int{1}).Dedup().Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// A comparable type holding an incomparable value
+	func() {
+		defer func() {
+			assert.Equal(t, ErrDedupComparable, recover())
+		}()
+
+		Of(KeyValue{1, 1}, KeyValue{[]int{1}, 1}).Dedup().ToSlice()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestScan(t *testing.T) {
+	sum := func(acc, val interface{}) interface{} { return acc.(int) + val.(int) }
+	assert.Equal(t, []interface{}{1, 3, 6}, Of(1, 2, 3).Scan(0, sum).ToSlice())
+	assert.Equal(t, []interface{}{11, 13}, Of(1, 2).Scan(10, sum).ToSlice())
+	assert.Equal(t, []interface{}{}, Of().Scan(0, sum).ToSlice())
+
+	concat := func(acc, val interface{}) interface{} { return acc.(string) + val.(string) }
+	assert.Equal(t, []interface{}{">a", ">ab", ">abc"}, Of("a", "b", "c").Scan(">", concat).ToSlice())
+
+	// Lazy
+	source := Of(1, 2, 3)
+	iter := source.Scan(0, sum)
+	assert.Equal(t, 1, iter.NextValue())
+	assert.Equal(t, 2, source.NextValue())
+	assert.Equal(t, []interface{}{4}, iter.ToSlice())
+}
+
 func TestIterablesFunc(t *testing.T) {
 	// No Iterables
 	iterFunc := IterablesFunc([]*Iterable{})()
@@ -4,6 +4,8 @@ package goiter
 
 import (
 	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 // RunePositionIter tracks the line number and rune position while reading UTF8 runes of an io.Reader.
@@ -17,6 +19,13 @@ type RunePositionIter struct {
 	lastReadWasEOF bool
 	line           int
 	position       int
+	column         int
+	offset         int
+	nextOffset     int
+	lastRead       rune
+	lastLen        int
+	unreadLens     []int
+	tabWidth       int
 }
 
 // NewRunePositionIter constructs a new RunePositionIter from an io.Reader
@@ -30,6 +39,27 @@ func NewRunePositionIter(src io.Reader) *RunePositionIter {
 	}
 }
 
+// NewRunePositionIterNormalizeEOL constructs a new RunePositionIter from an io.Reader, where CR, LF, and CRLF are each returned as a single LF,
+// and each increments the line once. A CR that is not followed by an LF is still one EOL.
+// NewRunePositionIter already normalizes EOLs this way, this constructor makes that explicit for callers that depend on it.
+func NewRunePositionIterNormalizeEOL(src io.Reader) *RunePositionIter {
+	return NewRunePositionIter(src)
+}
+
+// NewRunePositionIterTabWidth constructs a new RunePositionIter from an io.Reader, where a tab advances the position to the next tab stop.
+// Tab stops are every tabWidth positions, so with a tabWidth of 4, the tab stops are positions 5, 9, 13, etc.
+// Panics if tabWidth <= 0.
+func NewRunePositionIterTabWidth(src io.Reader, tabWidth int) *RunePositionIter {
+	if tabWidth <= 0 {
+		panic(ErrTabWidthGreaterThanZero)
+	}
+
+	rp := NewRunePositionIter(src)
+	rp.tabWidth = tabWidth
+
+	return rp
+}
+
 // Next returns true if there is another rune to be read by Value.
 func (rp *RunePositionIter) Next() bool {
 	if rp.iter == nil {
@@ -50,18 +80,31 @@ func (rp *RunePositionIter) Next() bool {
 	if next = rp.iter.Next(); next {
 		// Get next char and handle EOL any sequence, if present
 		rp.lastChar = rp.iter.RuneValue()
+		rp.column = rp.position
+
+		// Get number of bytes in char, which is known if it was unread
+		var numBytes int
+		if n := len(rp.unreadLens); n > 0 {
+			numBytes = rp.unreadLens[n-1]
+			rp.unreadLens = rp.unreadLens[:n-1]
+		} else {
+			numBytes = utf8.RuneLen(rp.lastChar)
+		}
 
 		switch rp.lastChar {
 		case '\r':
 			// Increase line and flag it
 			rp.line++
 			rp.position = 1
+			rp.column = 0
 
 			// If it is a CRLF, consume the LF
 			if rp.iter.Next() {
 				if peek := rp.iter.RuneValue(); peek != '\n' {
 					// Just a CR, unread this second char
 					rp.iter.Unread(peek)
+				} else {
+					numBytes++
 				}
 			} else {
 				// Unable to peek at next char because there is no next char.
@@ -75,11 +118,25 @@ func (rp *RunePositionIter) Next() bool {
 		case '\n':
 			rp.line++
 			rp.position = 1
+			rp.column = 0
+
+		case '\t':
+			if rp.tabWidth > 0 {
+				// Advance position to next tab stop
+				rp.position = ((rp.position-1)/rp.tabWidth+1)*rp.tabWidth + 1
+			} else {
+				rp.position++
+			}
 
 		default:
 			// Increment position in line - since EOLs reset to 0, it will always be >= 1 for non-EOL chars
 			rp.position++
 		}
+
+		rp.offset = rp.nextOffset
+		rp.nextOffset += numBytes
+		rp.lastRead = rp.lastChar
+		rp.lastLen = numBytes
 	}
 
 	return next
@@ -101,8 +158,52 @@ func (rp *RunePositionIter) Value() rune {
 	return result
 }
 
-// Unread unreads the given character
+// RestOfLine reads and returns the runes up to but not including the next EOL sequence or EOF.
+// The EOL sequence is not read, so the next call to Next reads it.
+// Line, Position, Column, and Offset reflect the last rune of the returned string, as if each rune had been read by Next.
+// Panics if the iterator is exhausted.
+func (rp *RunePositionIter) RestOfLine() string {
+	if rp.iter == nil {
+		panic(ErrNextExhaustedIter)
+	}
+
+	var str strings.Builder
+
+	for !rp.lastReadWasEOF {
+		// Peek at the next char without handling it, so an EOL sequence is not read
+		if !rp.iter.Next() {
+			// Flag EOF so that the next call to Next returns false
+			rp.lastReadWasEOF = true
+			break
+		}
+
+		char := rp.iter.RuneValue()
+		rp.iter.Unread(char)
+
+		if (char == '\r') || (char == '\n') {
+			break
+		}
+
+		rp.Next()
+		str.WriteRune(rp.Value())
+	}
+
+	return str.String()
+}
+
+// Unread unreads the given character.
+// The offset of the next rune read is moved back by the number of bytes in the character,
+// so that the character has the same Offset when it is read again.
+// If the character is the last one read, the number of bytes it was read from is used, so that an unread LF that was a CRLF moves back two bytes.
 func (rp *RunePositionIter) Unread(char rune) {
+	numBytes := utf8.RuneLen(char)
+	if (rp.lastLen > 0) && (char == rp.lastRead) {
+		numBytes = rp.lastLen
+	}
+	rp.lastLen = 0
+
+	rp.nextOffset -= numBytes
+	rp.unreadLens = append(rp.unreadLens, numBytes)
 	rp.iter.Unread(char)
 }
 
@@ -116,6 +217,20 @@ func (rp *RunePositionIter) Position() int {
 	return rp.position
 }
 
+// Column returns the column on the current line of the last rune read, starting at 1.
+// Position is the column of the next rune to be read, so Column is the value Position had before the last rune was read.
+// When the last rune read is an EOL, Line has already advanced to the next line, and Column is 0 to indicate the EOL is before the first column.
+// Before the first rune is read, Column is 0.
+func (rp *RunePositionIter) Column() int {
+	return rp.column
+}
+
+// Offset returns the byte offset of the first byte of the last rune read, starting at 0.
+// For an EOL that was read from a CRLF sequence, it is the offset of the CR.
+func (rp *RunePositionIter) Offset() int {
+	return rp.offset
+}
+
 // Iter is Iterable interface
 func (rp *RunePositionIter) Iter() *Iter {
 	return NewIter(
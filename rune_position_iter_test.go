@@ -90,3 +90,167 @@ func TestRunePositionIter(t *testing.T) {
 		assert.Fail(t, "Must panic")
 	}()
 }
+
+func TestRunePositionIterOffset(t *testing.T) {
+	var (
+		iter    = NewRunePositionIter(strings.NewReader("aé𝆑\r\nb\rc"))
+		chars   = []rune{'a', 'é', '𝆑', '\n', 'b', '\n', 'c'}
+		offsets = []int{0, 1, 3, 7, 9, 10, 11}
+	)
+
+	for i, char := range chars {
+		assert.True(t, iter.Next())
+		assert.Equal(t, char, iter.Value())
+		assert.Equal(t, offsets[i], iter.Offset())
+	}
+
+	assert.False(t, iter.Next())
+
+	// Unread multi byte runes and a CRLF
+	iter = NewRunePositionIter(strings.NewReader("é\r\n𝆑"))
+	assert.True(t, iter.Next())
+	assert.Equal(t, 'é', iter.Value())
+	assert.Equal(t, 0, iter.Offset())
+
+	assert.True(t, iter.Next())
+	assert.Equal(t, '\n', iter.Value())
+	assert.Equal(t, 2, iter.Offset())
+
+	iter.Unread('\n')
+	assert.True(t, iter.Next())
+	assert.Equal(t, '\n', iter.Value())
+	assert.Equal(t, 2, iter.Offset())
+
+	assert.True(t, iter.Next())
+	assert.Equal(t, '𝆑', iter.Value())
+	assert.Equal(t, 4, iter.Offset())
+
+	iter.Unread('𝆑')
+	iter.Unread('x')
+	assert.True(t, iter.Next())
+	assert.Equal(t, 'x', iter.Value())
+	assert.Equal(t, 3, iter.Offset())
+
+	assert.True(t, iter.Next())
+	assert.Equal(t, '𝆑', iter.Value())
+	assert.Equal(t, 4, iter.Offset())
+
+	assert.False(t, iter.Next())
+}
+
+func TestRunePositionIterTabWidth(t *testing.T) {
+	iter := NewRunePositionIterTabWidth(strings.NewReader("a\tb\t\tc\nd\te"), 4)
+
+	// Position after each char is the position of the next char
+	for _, position := range []int{2, 5, 6, 9, 13, 14, 1, 2, 5, 6} {
+		assert.True(t, iter.Next())
+		iter.Value()
+		assert.Equal(t, position, iter.Position())
+	}
+
+	assert.False(t, iter.Next())
+
+	// Without a tab width, a tab is one position
+	iter = NewRunePositionIter(strings.NewReader("a\tb"))
+	for _, position := range []int{2, 3, 4} {
+		assert.True(t, iter.Next())
+		iter.Value()
+		assert.Equal(t, position, iter.Position())
+	}
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrTabWidthGreaterThanZero, recover())
+		}()
+
+		NewRunePositionIterTabWidth(strings.NewReader("a"), 0)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestRunePositionIterColumn(t *testing.T) {
+	var (
+		iter    = NewRunePositionIter(strings.NewReader("ab\ncd\r\ne"))
+		chars   = []rune{'a', 'b', '\n', 'c', 'd', '\n', 'e'}
+		lines   = []int{1, 1, 2, 2, 2, 3, 3}
+		columns = []int{1, 2, 0, 1, 2, 0, 1}
+	)
+
+	assert.Equal(t, 1, iter.Line())
+	assert.Equal(t, 0, iter.Column())
+
+	for i, char := range chars {
+		assert.True(t, iter.Next())
+		assert.Equal(t, char, iter.Value())
+		assert.Equal(t, lines[i], iter.Line())
+		assert.Equal(t, columns[i], iter.Column())
+	}
+
+	assert.False(t, iter.Next())
+
+	// Tabs
+	iter = NewRunePositionIterTabWidth(strings.NewReader("a\tb"), 4)
+	for _, column := range []int{1, 2, 5} {
+		assert.True(t, iter.Next())
+		iter.Value()
+		assert.Equal(t, column, iter.Column())
+	}
+}
+
+func TestRunePositionIterNormalizeEOL(t *testing.T) {
+	// CR, LF, and CRLF are each a single LF that increments the line once
+	var (
+		iter  = NewRunePositionIterNormalizeEOL(strings.NewReader("a\rb\nc\r\nd"))
+		chars = []rune{'a', '\n', 'b', '\n', 'c', '\n', 'd'}
+		lines = []int{1, 2, 2, 3, 3, 4, 4}
+	)
+
+	for i, char := range chars {
+		assert.True(t, iter.Next())
+		assert.Equal(t, char, iter.Value())
+		assert.Equal(t, lines[i], iter.Line())
+	}
+
+	assert.False(t, iter.Next())
+}
+
+func TestRunePositionIterRestOfLine(t *testing.T) {
+	var (
+		iter = NewRunePositionIter(strings.NewReader("ab\r\ncd\ref\ngh"))
+		// For each line, the rune read before calling RestOfLine, and the rest of the line
+		firsts = []rune{'a', 'c', 'e', 'g'}
+		rests  = []string{"b", "d", "f", "h"}
+	)
+
+	for i, first := range firsts {
+		assert.True(t, iter.Next())
+		assert.Equal(t, first, iter.Value())
+
+		// Mid line
+		assert.Equal(t, rests[i], iter.RestOfLine())
+		assert.Equal(t, i+1, iter.Line())
+		assert.Equal(t, 2, iter.Column())
+		assert.Equal(t, 3, iter.Position())
+
+		if i < len(firsts)-1 {
+			// Positioned at the EOL
+			assert.True(t, iter.Next())
+			assert.Equal(t, '\n', iter.Value())
+			assert.Equal(t, i+2, iter.Line())
+			assert.Equal(t, 1, iter.Position())
+		}
+	}
+
+	// Last line without an EOL
+	assert.Equal(t, "", iter.RestOfLine())
+	assert.False(t, iter.Next())
+
+	// Already at an EOL
+	iter = NewRunePositionIter(strings.NewReader("\nbc"))
+	assert.Equal(t, "", iter.RestOfLine())
+	assert.True(t, iter.Next())
+	assert.Equal(t, '\n', iter.Value())
+	assert.Equal(t, "bc", iter.RestOfLine())
+	assert.Equal(t, 2, iter.Offset())
+	assert.False(t, iter.Next())
+}
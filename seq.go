@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package goiter
+
+import (
+	"iter"
+)
+
+// Seq returns an iter.Seq that yields the values of this Iter, so that it can be used in a for range loop.
+// If the loop exits early, no further values are read from this Iter.
+func (it *Iter) Seq() iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		for it.Next() {
+			if !yield(it.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// FromSeq constructs an Iter that iterates the values of an iter.Seq.
+// The iter.Seq is converted with iter.Pull, and stopped once it is exhausted.
+// If the Iter is abandoned before it is exhausted, Close must be called on it, or on an Iter chained after it, to stop the iter.Seq,
+// otherwise the goroutine used by iter.Pull is not released.
+func FromSeq(seq iter.Seq[interface{}]) *Iter {
+	next, stop := iter.Pull(seq)
+
+	result := NewIter(func() (interface{}, bool) {
+		value, haveIt := next()
+		if !haveIt {
+			stop()
+		}
+
+		return value, haveIt
+	})
+	result.closer = stop
+
+	return result
+}
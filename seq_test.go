@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package goiter
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeq(t *testing.T) {
+	values := []interface{}{}
+	for value := range Of(1, 2, 3).Seq() {
+		values = append(values, value)
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, values)
+
+	// Early break does not read further values
+	var (
+		iter = Of(1, 2, 3)
+		seen = []interface{}{}
+	)
+
+	for value := range iter.Seq() {
+		seen = append(seen, value)
+		if value == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []interface{}{1, 2}, seen)
+	assert.Equal(t, 3, iter.NextValue())
+
+	// Empty
+	for range Of().Seq() {
+		assert.Fail(t, "Must not iterate")
+	}
+}
+
+func TestFromSeq(t *testing.T) {
+	iter := FromSeq(slices.Values([]interface{}{1, 2, 3}))
+	assert.Equal(t, []interface{}{1, 2, 3}, iter.ToSlice())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		iter.Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Round trip
+	assert.Equal(t, []interface{}{"a", "b"}, FromSeq(Of("a", "b").Seq()).ToSlice())
+
+	// Early stop does not read further values
+	yielded := 0
+	seq := func(yield func(interface{}) bool) {
+		for i := 1; i <= 3; i++ {
+			yielded++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	assert.Equal(t, []interface{}{1}, FromSeq(seq).Limit(1).ToSlice())
+	assert.Equal(t, 1, yielded)
+
+	assert.Equal(t, []interface{}{}, FromSeq(slices.Values([]interface{}{})).ToSlice())
+
+	// Close stops the iter.Seq early, so it can clean up
+	var (
+		cleanedUp bool
+		infinite  = func(yield func(interface{}) bool) {
+			defer func() { cleanedUp = true }()
+
+			for i := 0; yield(i); i++ {
+			}
+		}
+	)
+
+	iter = FromSeq(infinite)
+	assert.Equal(t, 0, iter.NextValue())
+	assert.Equal(t, 1, iter.NextValue())
+	assert.False(t, cleanedUp)
+
+	iter.Close()
+	assert.True(t, cleanedUp)
+	assert.False(t, iter.Next())
+
+	// Closing an Iter chained after FromSeq stops the iter.Seq too
+	cleanedUp = false
+	iter = FromSeq(infinite).Limit(5).Map(func(value interface{}) interface{} { return value.(int) * 10 })
+	assert.Equal(t, 0, iter.NextValue())
+	assert.Equal(t, 10, iter.NextValue())
+	assert.False(t, cleanedUp)
+
+	iter.Close()
+	assert.True(t, cleanedUp)
+	assert.False(t, iter.Next())
+}
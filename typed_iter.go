@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package goiter
+
+// TypedIter is an iterator of values of type T.
+// It behaves the same as Iter, except that values are not converted to and from interface{}, so no reflection is required.
+type TypedIter[T any] struct {
+	iter       func() (T, bool)
+	nextCalled bool
+	value      T
+}
+
+// NewTypedIter constructs a TypedIter from an iterating function.
+// The function must returns (nextItem, true) for every item available to iterate, then return (zero value, false) on the next call after the last item.
+// Once the function returns a false bool value, it will never be called again.
+// Panics if iter is nil.
+func NewTypedIter[T any](iter func() (T, bool)) *TypedIter[T] {
+	if iter == nil {
+		panic(ErrNewIterNeedsIterator)
+	}
+
+	return &TypedIter[T]{iter: iter}
+}
+
+// OfTyped constructs a TypedIter that iterates the items passed.
+func OfTyped[T any](items ...T) *TypedIter[T] {
+	idx := 0
+
+	return NewTypedIter(func() (T, bool) {
+		if idx == len(items) {
+			var zero T
+			return zero, false
+		}
+
+		value := items[idx]
+		idx++
+		return value, true
+	})
+}
+
+// Next returns true if there is another item to be read by Value.
+// Once Next returns false, further calls to Next panic.
+func (it *TypedIter[T]) Next() bool {
+	// Die if iterator already exhausted
+	if it.iter == nil {
+		panic(ErrNextExhaustedIter)
+	}
+
+	if value, haveIt := it.iter(); haveIt {
+		it.nextCalled = true
+		it.value = value
+		return true
+	}
+
+	// First call with no more items, mark done and return false
+	it.iter = nil
+	return false
+}
+
+// Value returns the value retrieved by the prior call to Next.
+// Panics if the iterator is exhausted.
+// Panics if Next has not been called since the last time Value was called.
+func (it *TypedIter[T]) Value() T {
+	if it.iter == nil {
+		panic(ErrValueExhaustedIter)
+	}
+
+	if !it.nextCalled {
+		panic(ErrValueNextFirst)
+	}
+
+	// Clear nextCalled flag
+	it.nextCalled = false
+	return it.value
+}
+
+// Filter returns a TypedIter that lazily returns only the values of this TypedIter for which fn returns true.
+func (it *TypedIter[T]) Filter(fn func(T) bool) *TypedIter[T] {
+	return NewTypedIter(func() (T, bool) {
+		for it.Next() {
+			if value := it.Value(); fn(value) {
+				return value, true
+			}
+		}
+
+		var zero T
+		return zero, false
+	})
+}
+
+// Map returns a TypedIter that lazily returns the result of applying fn to each value of this TypedIter.
+func (it *TypedIter[T]) Map(fn func(T) T) *TypedIter[T] {
+	return NewTypedIter(func() (T, bool) {
+		if it.Next() {
+			return fn(it.Value()), true
+		}
+
+		var zero T
+		return zero, false
+	})
+}
+
+// ToSlice collects the values of this TypedIter into a []T.
+// This operation will exhaust the iter.
+// If the iter is empty, an allocated empty slice is returned.
+func (it *TypedIter[T]) ToSlice() []T {
+	result := []T{}
+
+	for it.Next() {
+		result = append(result, it.Value())
+	}
+
+	return result
+}
+
+// Iter returns an Iter that lazily returns the values of this TypedIter as interface{}, so that Iter methods can be used.
+func (it *TypedIter[T]) Iter() *Iter {
+	return NewIter(func() (interface{}, bool) {
+		if it.Next() {
+			return it.Value(), true
+		}
+
+		return nil, false
+	})
+}
+
+// MapTo returns a TypedIter that lazily returns the result of applying fn to each value of a TypedIter,
+// where the result can be a different type than the values.
+// It is a function rather than a method, as methods cannot have type parameters.
+func MapTo[T, U any](in *TypedIter[T], fn func(T) U) *TypedIter[U] {
+	return NewTypedIter(func() (U, bool) {
+		if in.Next() {
+			return fn(in.Value()), true
+		}
+
+		var zero U
+		return zero, false
+	})
+}
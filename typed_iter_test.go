@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package goiter
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfTyped(t *testing.T) {
+	// int
+	ints := OfTyped(1, 2, 3)
+	assert.True(t, ints.Next())
+	assert.Equal(t, 1, ints.Value())
+	assert.Equal(t, []int{2, 3}, ints.ToSlice())
+
+	assert.Equal(t, []int{}, OfTyped[int]().ToSlice())
+
+	// string
+	assert.Equal(t, []string{"A", "B"}, OfTyped("a", "b").Map(strings.ToUpper).ToSlice())
+	assert.Equal(t, []string{"bb"}, OfTyped("a", "bb", "c").Filter(func(s string) bool { return len(s) > 1 }).ToSlice())
+
+	// Iter
+	assert.Equal(t, []interface{}{1, 2}, OfTyped(1, 2).Iter().ToSlice())
+
+	// Panics if Value called before Next
+	func() {
+		defer func() {
+			assert.Equal(t, ErrValueNextFirst, recover())
+		}()
+
+		OfTyped(1).Value()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Panics if Next or Value called after exhaustion
+	ints = OfTyped[int]()
+	assert.False(t, ints.Next())
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrValueExhaustedIter, recover())
+		}()
+
+		ints.Value()
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNextExhaustedIter, recover())
+		}()
+
+		ints.Next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(t, ErrNewIterNeedsIterator, recover())
+		}()
+
+		NewTypedIter[int](nil)
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestMapTo(t *testing.T) {
+	var (
+		calls   int
+		strs    = MapTo(OfTyped(1, 2, 3), func(i int) string { calls++; return strconv.Itoa(i) })
+		lengths = MapTo(strs, func(s string) int { return len(s) })
+	)
+
+	// Lazy
+	assert.Equal(t, 0, calls)
+	assert.True(t, lengths.Next())
+	assert.Equal(t, 1, lengths.Value())
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, []int{1, 1}, lengths.ToSlice())
+	assert.Equal(t, 3, calls)
+
+	assert.Equal(t, []string{"1", "2"}, MapTo(OfTyped(1, 2), strconv.Itoa).ToSlice())
+
+	assert.Equal(t, []string{}, MapTo(OfTyped[int](), strconv.Itoa).ToSlice())
+}